@@ -0,0 +1,61 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Cache 缓存接口，值为序列化后的字节
+type Cache interface {
+	// Get 读取缓存，不存在或已过期时ok为false
+	Get(ctx context.Context, key string) (value []byte, ok bool, err error)
+	// Set 写入缓存，ttl为0时不过期
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete 删除缓存，key不存在时不返回错误
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// 同一进程内相同key的并发加载只执行一次
+var loads singleflight.Group
+
+// GetOrLoad 先读缓存，未命中时调用load从数据源加载并写入缓存，结果以JSON解码到dest
+// 缓存不可用时直接从数据源加载，不影响请求；并发未命中时只有一个请求执行load，其他请求等待并共享结果
+func GetOrLoad(ctx context.Context, c Cache, key string, ttl time.Duration, dest interface{}, load func() (interface{}, error)) error {
+	if data, ok, err := c.Get(ctx, key); err != nil {
+		log.Printf("读取缓存失败 %s: %v", key, err)
+	} else if ok {
+		if err := json.Unmarshal(data, dest); err == nil {
+			return nil
+		}
+	}
+
+	data, err, _ := loads.Do(key, func() (interface{}, error) {
+		value, err := load()
+		if err != nil {
+			return nil, err
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.Set(ctx, key, data, ttl); err != nil {
+			log.Printf("写入缓存失败 %s: %v", key, err)
+		}
+		return data, nil
+	})
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data.([]byte), dest)
+}
+
+// Invalidate 删除缓存，失败时只记录日志，依靠过期时间兜底
+func Invalidate(ctx context.Context, c Cache, keys ...string) {
+	if err := c.Delete(ctx, keys...); err != nil {
+		log.Printf("删除缓存失败 %v: %v", keys, err)
+	}
+}
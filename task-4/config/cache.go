@@ -0,0 +1,36 @@
+package config
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/xhy/blog-api/cache"
+)
+
+var Cache cache.Cache
+
+// InitCache 初始化缓存
+func InitCache() {
+	config := GetConfig()
+
+	switch config.Cache.Driver {
+	case "redis":
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		redis, err := cache.NewRedis(ctx, cache.RedisConfig{
+			Addr:     config.Cache.Redis.Addr,
+			Password: config.Cache.Redis.Password,
+			DB:       config.Cache.Redis.DB,
+			Prefix:   config.Cache.Redis.Prefix,
+		})
+		if err != nil {
+			log.Fatalf("Failed to connect to redis: %v", err)
+		}
+		Cache = redis
+	default:
+		Cache = cache.NewMemory(config.Cache.Size)
+	}
+
+	log.Printf("Cache initialized (%s)", config.Cache.Driver)
+}
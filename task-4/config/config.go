@@ -4,14 +4,27 @@ import "time"
 
 // Config 应用配置
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	JWT      JWTConfig
+	Server     ServerConfig
+	Database   DatabaseConfig
+	JWT        JWTConfig
+	Upload     UploadConfig
+	Site       SiteConfig
+	Views      ViewsConfig
+	Cache      CacheConfig
+	Trash      TrashConfig
+	Moderation ModerationConfig
+	Filter     FilterConfig
+	Reports    ReportsConfig
+	GraphQL    GraphQLConfig
+	GRPC       GRPCConfig
 }
 
 // ServerConfig 服务器配置
 type ServerConfig struct {
 	Port string
+	// TrustedProxies 反向代理的IP或CIDR，只有来自这些地址的请求才使用X-Forwarded-For、X-Real-IP作为客户端IP，
+	// 为空时不信任任何代理，客户端IP取连接的对端地址
+	TrustedProxies []string
 }
 
 // DatabaseConfig 数据库配置
@@ -29,6 +42,116 @@ type DatabaseConfig struct {
 type JWTConfig struct {
 	Secret    string
 	ExpiresIn time.Duration
+	Algorithm string        // 签名算法：HS256、RS256、ES256、EdDSA
+	KeyDir    string        // 非对称算法的私钥目录，每个文件一把密钥，文件名即kid
+	ActiveKID string        // 用于签名的kid，为空时使用最新的密钥
+	Issuer    string        // 签发者（iss）
+	Audience  string        // 受众（aud）
+	Leeway    time.Duration // 时钟偏差容忍度
+	// 在此之前仍接受升级前签发的没有kid的HS256令牌（使用Secret验证），零值表示不接受，升级后这些令牌全部失效
+	LegacyUntil time.Time
+}
+
+// UploadConfig 附件上传配置
+type UploadConfig struct {
+	MaxSize  int64  // 单个文件大小上限（字节）
+	Driver   string // 存储后端：local 或 s3
+	LocalDir string // 本地存储目录
+	S3       S3Config
+}
+
+// S3Config S3兼容存储配置，本地调试可以指向MinIO
+type S3Config struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	UseSSL    bool
+}
+
+// SiteConfig 站点信息，用于生成订阅源等对外链接
+type SiteConfig struct {
+	URL             string // 站点对外访问的根地址，不带末尾斜杠
+	Title           string
+	Description     string
+	FeedSize        int      // 订阅源包含的文章数
+	FeedFullContent bool     // 订阅源输出全文，否则只输出摘要
+	RobotsDisallow  []string // robots.txt中禁止抓取的路径
+}
+
+// ViewsConfig 文章阅读量统计配置
+type ViewsConfig struct {
+	FlushInterval time.Duration // 批量写入数据库的间隔
+	DedupWindow   time.Duration // 同一访客重复阅读只计一次的时间窗口
+}
+
+// CacheConfig 文章读取缓存配置
+type CacheConfig struct {
+	Driver string        // 缓存后端：memory 或 redis
+	Size   int           // 进程内缓存最多保存的条目数
+	TTL    time.Duration // 缓存过期时间，删除缓存失败时也能在过期后恢复一致
+	Redis  RedisConfig
+}
+
+// RedisConfig Redis连接配置
+type RedisConfig struct {
+	Addr     string
+	Password string
+	DB       int
+	Prefix   string
+}
+
+// TrashConfig 回收站配置
+type TrashConfig struct {
+	RetentionDays int           // 删除的文章和评论在回收站中保留的天数，超过后彻底删除
+	PurgeInterval time.Duration // 检查并清理过期内容的间隔
+}
+
+// Retention 回收站的保留期限
+func (t TrashConfig) Retention() time.Duration {
+	return time.Duration(t.RetentionDays) * 24 * time.Hour
+}
+
+// ModerationConfig 评论审核配置
+type ModerationConfig struct {
+	Policy             string // 全站评论审核策略：auto、trusted 或 all，文章可以单独设置
+	TrustedMinApproved int    // trusted策略下，已发布评论达到该数量且没有垃圾评论的用户受信任
+}
+
+// FilterConfig 文章和评论的内容过滤配置
+type FilterConfig struct {
+	Enabled bool
+	// 屏蔽词，以re:开头的按正则表达式匹配；中文屏蔽词同时匹配繁简、全半角、
+	// 字间插入符号和同音字等变体
+	Blocklist         []string
+	MaxLinks          int           // 评论中链接数量上限，超过后等待审核
+	MaxPostLinks      int           // 文章中链接数量上限
+	DuplicateWindow   time.Duration // 与该时间段内的内容比较是否重复
+	DuplicateRecent   int           // 最多比较的最近内容数量
+	DuplicateDistance int           // SimHash的海明距离不超过该值视为重复
+	DuplicateMinRunes int           // 短于该长度的内容不检查重复
+	BayesMinDocs      int           // 垃圾评论和正常评论的训练样本都达到该数量后才启用分类器
+	BayesHold         float64       // 垃圾评论概率超过该值时等待审核
+	BayesReject       float64       // 垃圾评论概率超过该值时拒绝
+}
+
+// ReportsConfig 举报配置
+type ReportsConfig struct {
+	HideThreshold int // 未处理的举报达到该数量时自动隐藏内容，等待版主处理，为0时不自动隐藏
+}
+
+// GraphQLConfig GraphQL接口配置
+type GraphQLConfig struct {
+	MaxDepth      int // 查询的最大嵌套深度
+	MaxComplexity int // 查询的最大复杂度：每个字段计1，列表字段的子字段按返回数量倍增
+	ListSize      int // 未指定数量的列表字段估算复杂度时使用的数量
+}
+
+// GRPCConfig gRPC接口配置
+type GRPCConfig struct {
+	Port       string // 监听端口，为空（默认）时不启动gRPC服务；服务没有TLS，开启时应只对内网开放
+	Reflection bool   // 开启服务反射，便于grpcurl等工具调试，默认关闭
 }
 
 // GetConfig 返回应用配置
@@ -49,6 +172,76 @@ func GetConfig() *Config {
 		JWT: JWTConfig{
 			Secret:    "wsykxhy999",
 			ExpiresIn: 24 * time.Hour,
+			Algorithm: "ES256",
+			KeyDir:    "keys/jwt",
+			Issuer:    "blog-api",
+			Audience:  "blog-api",
+			Leeway:    30 * time.Second,
+		},
+		Upload: UploadConfig{
+			MaxSize:  10 << 20,
+			Driver:   "local",
+			LocalDir: "uploads",
+			S3: S3Config{
+				Endpoint:  "localhost:9000",
+				Region:    "us-east-1",
+				Bucket:    "blog-attachments",
+				AccessKey: "minioadmin",
+				SecretKey: "minioadmin",
+			},
+		},
+		Site: SiteConfig{
+			URL:             "http://localhost:8090",
+			Title:           "博客",
+			Description:     "最新文章",
+			FeedSize:        20,
+			FeedFullContent: true,
+			RobotsDisallow:  []string{"/api/me/", "/api/feed", "/api/notifications"},
+		},
+		Views: ViewsConfig{
+			FlushInterval: 10 * time.Second,
+			DedupWindow:   30 * time.Minute,
+		},
+		Cache: CacheConfig{
+			Driver: "memory",
+			Size:   10000,
+			TTL:    time.Minute,
+			Redis: RedisConfig{
+				Addr:   "localhost:6379",
+				Prefix: "blog:",
+			},
+		},
+		Trash: TrashConfig{
+			RetentionDays: 30,
+			PurgeInterval: time.Hour,
+		},
+		Moderation: ModerationConfig{
+			Policy:             "auto",
+			TrustedMinApproved: 3,
+		},
+		Filter: FilterConfig{
+			Enabled:           true,
+			MaxLinks:          3,
+			MaxPostLinks:      30,
+			DuplicateWindow:   7 * 24 * time.Hour,
+			DuplicateRecent:   20,
+			DuplicateDistance: 3,
+			DuplicateMinRunes: 30,
+			BayesMinDocs:      20,
+			BayesHold:         0.9,
+			BayesReject:       0.99,
+		},
+		Reports: ReportsConfig{
+			HideThreshold: 3,
+		},
+		GraphQL: GraphQLConfig{
+			MaxDepth:      8,
+			MaxComplexity: 1000,
+			ListSize:      10,
+		},
+		GRPC: GRPCConfig{
+			Port:       "",
+			Reflection: false,
 		},
 	}
 }
@@ -0,0 +1,39 @@
+package config
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/xhy/blog-api/storage"
+)
+
+var Storage storage.Storage
+
+// InitStorage 初始化附件存储
+func InitStorage() {
+	config := GetConfig()
+
+	var err error
+	switch config.Upload.Driver {
+	case "s3":
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		s3 := config.Upload.S3
+		Storage, err = storage.NewS3(ctx, storage.S3Config{
+			Endpoint:  s3.Endpoint,
+			Region:    s3.Region,
+			Bucket:    s3.Bucket,
+			AccessKey: s3.AccessKey,
+			SecretKey: s3.SecretKey,
+			UseSSL:    s3.UseSSL,
+		})
+	default:
+		Storage, err = storage.NewLocal(config.Upload.LocalDir)
+	}
+	if err != nil {
+		log.Fatalf("Failed to initialize storage: %v", err)
+	}
+
+	log.Printf("Storage initialized (%s)", config.Upload.Driver)
+}
@@ -0,0 +1,346 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xhy/blog-api/config"
+	"github.com/xhy/blog-api/models"
+	"gorm.io/gorm"
+)
+
+// 站点统计最多返回的天数
+const maxStatsDays = 365
+
+// GetUsers 获取用户列表，最新注册的在前，支持按用户名或邮箱搜索、按角色和状态筛选（仅管理员）
+func GetUsers(c *gin.Context) {
+	var users []models.User
+
+	if _, ok := requireAdmin(c); !ok {
+		return
+	}
+
+	// 分页参数
+	pageSize, offset := parsePagination(c)
+
+	query := config.DB.Model(&models.User{})
+	if q := c.Query("q"); q != "" {
+		like := "%" + q + "%"
+		query = query.Where("username LIKE ? OR email LIKE ?", like, like)
+	}
+	if role := c.Query("role"); role != "" {
+		query = query.Where("role = ?", role)
+	}
+	switch c.Query("status") {
+	case "active":
+		query = query.Where("suspended_at IS NULL")
+	case "suspended":
+		query = query.Where("suspended_at IS NOT NULL")
+	}
+
+	if err := query.Order("id desc").Limit(pageSize).Offset(offset).Find(&users).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "获取用户列表失败: " + err.Error(),
+		})
+		return
+	}
+
+	managed := make([]models.ManagedUser, len(users))
+	for i := range users {
+		managed[i] = models.NewManagedUser(&users[i])
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Code:    http.StatusOK,
+		Message: "获取用户列表成功",
+		Data:    managed,
+	})
+}
+
+// SuspendUser 停用用户，停用后无法登录，已签发的令牌也被拒绝（仅管理员）
+func SuspendUser(c *gin.Context) {
+	var input models.SuspendInput
+
+	admin, ok := requireAdmin(c)
+	if !ok {
+		return
+	}
+	user, ok := findManagedUser(c, admin, "不能停用自己")
+	if !ok {
+		return
+	}
+
+	// 绑定请求数据，停用原因可以省略
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, models.Response{
+				Code:    http.StatusBadRequest,
+				Message: "请求参数错误: " + err.Error(),
+			})
+			return
+		}
+	}
+
+	now := time.Now()
+	updateManagedUser(c, user, map[string]interface{}{"suspended_at": now, "suspend_reason": input.Reason}, "停用用户")
+}
+
+// UnsuspendUser 恢复被停用的用户（仅管理员）
+func UnsuspendUser(c *gin.Context) {
+	admin, ok := requireAdmin(c)
+	if !ok {
+		return
+	}
+	user, ok := findManagedUser(c, admin, "不能恢复自己")
+	if !ok {
+		return
+	}
+
+	updateManagedUser(c, user, map[string]interface{}{"suspended_at": nil, "suspend_reason": ""}, "恢复用户")
+}
+
+// updateManagedUser 修改用户的停用状态并写入审计日志，返回修改后的用户
+func updateManagedUser(c *gin.Context, user *models.User, columns map[string]interface{}, action string) {
+	// 审计日志同样使用ManagedUser，记录停用时间和原因的变化
+	before := models.NewManagedUser(user)
+	if err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).UpdateColumns(columns).Error; err != nil {
+			return err
+		}
+		if err := tx.First(user, user.ID).Error; err != nil {
+			return err
+		}
+		after := models.NewManagedUser(user)
+		return recordAudit(c, tx, models.AuditUpdate, models.TargetUser, user.ID, &before, &after)
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: action + "失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Code:    http.StatusOK,
+		Message: action + "成功",
+		Data:    models.NewManagedUser(user),
+	})
+}
+
+// LogoutUser 强制用户下线：此前签发的JWT全部失效，个人访问令牌全部撤销（仅管理员）
+func LogoutUser(c *gin.Context) {
+	admin, ok := requireAdmin(c)
+	if !ok {
+		return
+	}
+	user, ok := findManagedUser(c, admin, "")
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	var revoked int64
+	if err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).UpdateColumn("sessions_revoked_at", now).Error; err != nil {
+			return err
+		}
+		result := tx.Where("user_id = ?", user.ID).Delete(&models.PersonalAccessToken{})
+		if result.Error != nil {
+			return result.Error
+		}
+		revoked = result.RowsAffected
+		return recordAudit(c, tx, models.AuditUpdate, models.TargetUser, user.ID,
+			gin.H{"sessions_revoked_at": user.SessionsRevokedAt}, gin.H{"sessions_revoked_at": now})
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "强制下线失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Code:    http.StatusOK,
+		Message: "强制下线成功",
+		Data:    gin.H{"revoked_tokens": revoked},
+	})
+}
+
+// DeleteUser 删除用户，content=reassign时文章和评论转给reassign_to指定的用户，
+// content=delete时文章移入回收站、评论删除（仅管理员）
+func DeleteUser(c *gin.Context) {
+	admin, ok := requireAdmin(c)
+	if !ok {
+		return
+	}
+	user, ok := findManagedUser(c, admin, "不能删除自己")
+	if !ok {
+		return
+	}
+
+	content := c.Query("content")
+	var heir models.User
+	switch content {
+	case models.UserContentDelete:
+	case models.UserContentReassign:
+		heirID, ok := parseID(c, "reassign_to", c.Query("reassign_to"))
+		if !ok {
+			return
+		}
+		if err := config.DB.First(&heir, heirID).Error; err != nil || heir.ID == user.ID {
+			c.JSON(http.StatusBadRequest, models.Response{
+				Code:    http.StatusBadRequest,
+				Message: "reassign_to必须是其他已存在的用户",
+			})
+			return
+		}
+	default:
+		c.JSON(http.StatusBadRequest, models.Response{
+			Code:    http.StatusBadRequest,
+			Message: "content必须为reassign或delete",
+		})
+		return
+	}
+
+	var changes models.UserContentChanges
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var events []models.AuditEvent
+		var err error
+		if content == models.UserContentReassign {
+			if changes, err = models.ReassignUserContent(tx, user.ID, heir.ID); err != nil {
+				return err
+			}
+			before, after := gin.H{"user_id": user.ID}, gin.H{"user_id": heir.ID}
+			for _, id := range changes.Posts {
+				events = append(events, auditEvent(c, models.AuditUpdate, models.TargetPost, id, before, after))
+			}
+			for _, id := range changes.Comments {
+				events = append(events, auditEvent(c, models.AuditUpdate, models.TargetComment, id, before, after))
+			}
+		} else {
+			if changes, err = models.RemoveUserContent(tx, user.ID); err != nil {
+				return err
+			}
+			for _, id := range changes.Posts {
+				events = append(events, auditEvent(c, models.AuditDelete, models.TargetPost, id, nil, nil))
+			}
+			for _, id := range changes.Comments {
+				events = append(events, auditEvent(c, models.AuditDelete, models.TargetComment, id, nil, nil))
+			}
+		}
+
+		if err := models.DeleteUser(tx, user); err != nil {
+			return err
+		}
+		deleted := models.NewManagedUser(user)
+		events = append(events, auditEvent(c, models.AuditDelete, models.TargetUser, user.ID, &deleted, nil))
+		return tx.CreateInBatches(&events, 100).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "删除用户失败: " + err.Error(),
+		})
+		return
+	}
+
+	for _, id := range changes.Posts {
+		if content == models.UserContentDelete {
+			sitemapRemove(id)
+		}
+		invalidatePost(c, id)
+	}
+	// 其他文章下的评论被删除或转移，这些文章的详情、评论列表和评论数量同样需要刷新
+	for _, id := range changes.CommentPosts {
+		invalidatePost(c, id)
+	}
+	invalidatePostList(c)
+
+	c.JSON(http.StatusOK, models.Response{
+		Code:    http.StatusOK,
+		Message: "删除用户成功",
+		Data:    gin.H{"posts": len(changes.Posts), "comments": len(changes.Comments)},
+	})
+}
+
+// GetSiteStats 获取站点统计：用户、文章和评论总数，以及最近days天（默认30天）每天新增的数量（仅管理员）
+func GetSiteStats(c *gin.Context) {
+	if _, ok := requireAdmin(c); !ok {
+		return
+	}
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || days < 1 || days > maxStatsDays {
+		c.JSON(http.StatusBadRequest, models.Response{
+			Code:    http.StatusBadRequest,
+			Message: "days必须是1到" + strconv.Itoa(maxStatsDays) + "之间的整数",
+		})
+		return
+	}
+
+	stats, err := models.LoadSiteStats(config.DB, days)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "获取站点统计失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Code:    http.StatusOK,
+		Message: "获取站点统计成功",
+		Data:    stats,
+	})
+}
+
+// findManagedUser 查询管理员要操作的用户，self不为空时禁止操作自己，失败时写入响应
+func findManagedUser(c *gin.Context, admin *models.User, self string) (*models.User, bool) {
+	var user models.User
+	id, ok := parseID(c, "id", c.Param("id"))
+	if !ok {
+		return nil, false
+	}
+	if err := config.DB.First(&user, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, models.Response{
+				Code:    http.StatusNotFound,
+				Message: "用户不存在",
+			})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "获取用户失败: " + err.Error(),
+		})
+		return nil, false
+	}
+
+	if self != "" && user.ID == admin.ID {
+		c.JSON(http.StatusBadRequest, models.Response{
+			Code:    http.StatusBadRequest,
+			Message: self,
+		})
+		return nil, false
+	}
+	return &user, true
+}
+
+// requireAdmin 要求当前用户为管理员，否则写入403响应
+func requireAdmin(c *gin.Context) (*models.User, bool) {
+	user, ok := currentUser(c)
+	if !ok {
+		return nil, false
+	}
+	if !user.IsAdmin() {
+		c.JSON(http.StatusForbidden, models.Response{
+			Code:    http.StatusForbidden,
+			Message: "需要管理员权限",
+		})
+		return nil, false
+	}
+	return user, true
+}
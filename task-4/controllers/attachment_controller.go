@@ -0,0 +1,447 @@
+package controllers
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/gin-gonic/gin"
+	"github.com/xhy/blog-api/config"
+	"github.com/xhy/blog-api/models"
+	"github.com/xhy/blog-api/storage"
+	"github.com/xhy/blog-api/utils"
+	"gorm.io/gorm"
+)
+
+// 允许上传的文件类型，按文件内容识别，不信任客户端提供的Content-Type
+var allowedAttachmentTypes = map[string]bool{
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/gif":       true,
+	"application/pdf": true,
+	"text/plain":      true,
+}
+
+// UploadAttachment 上传文章附件（multipart表单字段file）
+func UploadAttachment(c *gin.Context) {
+	postID, ok := parseID(c, "id", c.Param("id"))
+	if !ok {
+		return
+	}
+	var post models.Post
+	maxSize := config.GetConfig().Upload.MaxSize
+
+	// 获取当前用户ID
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.Response{
+			Code:    http.StatusUnauthorized,
+			Message: "未授权",
+		})
+		return
+	}
+
+	// 查询文章是否存在
+	if err := config.DB.First(&post, postID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, models.Response{
+				Code:    http.StatusNotFound,
+				Message: "文章不存在",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "获取文章失败: " + err.Error(),
+		})
+		return
+	}
+
+	// 检查是否为文章作者
+	if post.UserID != userID.(uint) {
+		c.JSON(http.StatusForbidden, models.Response{
+			Code:    http.StatusForbidden,
+			Message: "没有权限为此文章上传附件",
+		})
+		return
+	}
+
+	// 限制请求体大小，预留multipart边界等开销
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize+1<<20)
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, models.Response{
+				Code:    http.StatusRequestEntityTooLarge,
+				Message: fmt.Sprintf("文件大小不能超过%dMB", maxSize>>20),
+			})
+			return
+		}
+		c.JSON(http.StatusBadRequest, models.Response{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+	if fileHeader.Size > maxSize {
+		c.JSON(http.StatusRequestEntityTooLarge, models.Response{
+			Code:    http.StatusRequestEntityTooLarge,
+			Message: fmt.Sprintf("文件大小不能超过%dMB", maxSize>>20),
+		})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{
+			Code:    http.StatusBadRequest,
+			Message: "读取文件失败: " + err.Error(),
+		})
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxSize))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{
+			Code:    http.StatusBadRequest,
+			Message: "读取文件失败: " + err.Error(),
+		})
+		return
+	}
+
+	// 根据文件内容识别类型
+	detected := mimetype.Detect(data)
+	contentType, _, _ := mime.ParseMediaType(detected.String())
+	if !allowedAttachmentTypes[contentType] {
+		c.JSON(http.StatusUnsupportedMediaType, models.Response{
+			Code:    http.StatusUnsupportedMediaType,
+			Message: "不支持的文件类型: " + contentType,
+		})
+		return
+	}
+
+	attachment := models.Attachment{
+		PostID:      post.ID,
+		UserID:      userID.(uint),
+		FileName:    sanitizeFileName(fileHeader.Filename),
+		ContentType: contentType,
+	}
+
+	// 图片去除EXIF并生成缩略图
+	var thumbnail []byte
+	if strings.HasPrefix(contentType, "image/") {
+		processed, err := utils.ProcessImage(data, contentType)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.Response{
+				Code:    http.StatusBadRequest,
+				Message: "图片处理失败: " + err.Error(),
+			})
+			return
+		}
+		data = processed.Data
+		thumbnail = processed.Thumbnail
+		attachment.ThumbnailType = processed.ThumbnailType
+		attachment.Width = processed.Width
+		attachment.Height = processed.Height
+	}
+
+	sum := sha256.Sum256(data)
+	attachment.SHA256 = hex.EncodeToString(sum[:])
+	attachment.Size = int64(len(data))
+
+	// 保存文件
+	name, err := randomObjectName()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "附件保存失败: " + err.Error(),
+		})
+		return
+	}
+	attachment.StorageKey = fmt.Sprintf("posts/%d/%s%s", post.ID, name, detected.Extension())
+	if err := config.Storage.Put(c.Request.Context(), attachment.StorageKey, bytes.NewReader(data), attachment.Size, contentType); err != nil {
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "附件保存失败: " + err.Error(),
+		})
+		return
+	}
+	if thumbnail != nil {
+		thumbExt := ".png"
+		if attachment.ThumbnailType == "image/jpeg" {
+			thumbExt = ".jpg"
+		}
+		attachment.ThumbnailKey = fmt.Sprintf("posts/%d/%s-thumb%s", post.ID, name, thumbExt)
+		if err := config.Storage.Put(c.Request.Context(), attachment.ThumbnailKey, bytes.NewReader(thumbnail), int64(len(thumbnail)), attachment.ThumbnailType); err != nil {
+			config.Storage.Delete(c.Request.Context(), attachment.StorageKey)
+			c.JSON(http.StatusInternalServerError, models.Response{
+				Code:    http.StatusInternalServerError,
+				Message: "缩略图保存失败: " + err.Error(),
+			})
+			return
+		}
+	}
+
+	if err := config.DB.Create(&attachment).Error; err != nil {
+		deleteAttachmentFiles(c, &attachment)
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "附件创建失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.Response{
+		Code:    http.StatusCreated,
+		Message: "附件上传成功",
+		Data:    attachment,
+	})
+}
+
+// GetAttachments 获取文章的附件列表
+func GetAttachments(c *gin.Context) {
+	postID, ok := parseID(c, "id", c.Param("id"))
+	if !ok {
+		return
+	}
+	var post models.Post
+	var attachments []models.Attachment
+
+	// 查询文章是否存在，被举报隐藏的文章只有作者可见
+	err := config.DB.First(&post, postID).Error
+	if err == nil && !canViewPost(c, &post) {
+		err = gorm.ErrRecordNotFound
+	}
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, models.Response{
+				Code:    http.StatusNotFound,
+				Message: "文章不存在",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "获取文章失败: " + err.Error(),
+		})
+		return
+	}
+
+	if err := config.DB.Where("post_id = ?", post.ID).Order("created_at asc").Find(&attachments).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "获取附件列表失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Code:    http.StatusOK,
+		Message: "获取附件列表成功",
+		Data:    attachments,
+	})
+}
+
+// ServeAttachment 下载附件
+func ServeAttachment(c *gin.Context) {
+	attachment, ok := findServableAttachment(c)
+	if !ok {
+		return
+	}
+	serveObject(c, attachment, attachment.StorageKey, attachment.ContentType)
+}
+
+// ServeThumbnail 获取图片附件的缩略图
+func ServeThumbnail(c *gin.Context) {
+	attachment, ok := findServableAttachment(c)
+	if !ok {
+		return
+	}
+	if !attachment.IsImage() {
+		c.JSON(http.StatusNotFound, models.Response{
+			Code:    http.StatusNotFound,
+			Message: "附件没有缩略图",
+		})
+		return
+	}
+	serveObject(c, attachment, attachment.ThumbnailKey, attachment.ThumbnailType)
+}
+
+// DeleteAttachment 删除附件
+func DeleteAttachment(c *gin.Context) {
+	id, ok := parseID(c, "id", c.Param("id"))
+	if !ok {
+		return
+	}
+	var attachment models.Attachment
+
+	// 获取当前用户ID
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.Response{
+			Code:    http.StatusUnauthorized,
+			Message: "未授权",
+		})
+		return
+	}
+
+	if err := config.DB.First(&attachment, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, models.Response{
+				Code:    http.StatusNotFound,
+				Message: "附件不存在",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "获取附件失败: " + err.Error(),
+		})
+		return
+	}
+
+	// 检查是否为上传者
+	if attachment.UserID != userID.(uint) {
+		c.JSON(http.StatusForbidden, models.Response{
+			Code:    http.StatusForbidden,
+			Message: "没有权限删除此附件",
+		})
+		return
+	}
+
+	// 单独删除附件时直接删除记录和文件
+	if err := config.DB.Unscoped().Delete(&attachment).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "删除附件失败: " + err.Error(),
+		})
+		return
+	}
+	deleteAttachmentFiles(c, &attachment)
+
+	c.JSON(http.StatusOK, models.Response{
+		Code:    http.StatusOK,
+		Message: "删除附件成功",
+	})
+}
+
+// findServableAttachment 查询附件，所属文章已删除或对当前用户隐藏时按不存在处理
+func findServableAttachment(c *gin.Context) (*models.Attachment, bool) {
+	var attachment models.Attachment
+	var post models.Post
+	id, ok := parseID(c, "id", c.Param("id"))
+	if !ok {
+		return nil, false
+	}
+	err := config.DB.First(&attachment, id).Error
+	if err == nil {
+		err = config.DB.Select("id", "user_id", "hidden").First(&post, attachment.PostID).Error
+	}
+	// 被举报隐藏的文章的附件只有作者可以访问，且不允许共享缓存
+	if err == nil && !canViewPost(c, &post) {
+		err = gorm.ErrRecordNotFound
+	}
+	if err == nil && post.Hidden {
+		c.Header("Cache-Control", "private, no-store")
+	}
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, models.Response{
+				Code:    http.StatusNotFound,
+				Message: "附件不存在",
+			})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "获取附件失败: " + err.Error(),
+		})
+		return nil, false
+	}
+	return &attachment, true
+}
+
+// serveObject 从存储读取文件并返回
+// 存储key唯一且内容不会变化，调用方没有设置Cache-Control时允许长期缓存；非图片文件强制下载，避免被当作页面执行
+func serveObject(c *gin.Context, attachment *models.Attachment, key, contentType string) {
+	etag := `"` + attachment.SHA256 + `"`
+	if key == attachment.ThumbnailKey {
+		etag = `"` + attachment.SHA256 + `-thumb"`
+	}
+	if c.Writer.Header().Get("Cache-Control") == "" {
+		c.Header("Cache-Control", "public, max-age=31536000, immutable")
+	}
+	c.Header("ETag", etag)
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	reader, info, err := config.Storage.Get(c.Request.Context(), key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			c.JSON(http.StatusNotFound, models.Response{
+				Code:    http.StatusNotFound,
+				Message: "附件文件不存在",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "读取附件失败: " + err.Error(),
+		})
+		return
+	}
+	defer reader.Close()
+
+	disposition := "attachment"
+	if strings.HasPrefix(contentType, "image/") {
+		disposition = "inline"
+	}
+	c.DataFromReader(http.StatusOK, info.Size, contentType, reader, map[string]string{
+		"Content-Disposition":     mime.FormatMediaType(disposition, map[string]string{"filename": attachment.FileName}),
+		"Last-Modified":           attachment.CreatedAt.UTC().Format(http.TimeFormat),
+		"X-Content-Type-Options":  "nosniff",
+		"Content-Security-Policy": "default-src 'none'; sandbox",
+	})
+}
+
+// deleteAttachmentFiles 删除附件在存储中的文件
+func deleteAttachmentFiles(c *gin.Context, attachment *models.Attachment) {
+	config.Storage.Delete(c.Request.Context(), attachment.StorageKey)
+	if attachment.ThumbnailKey != "" {
+		config.Storage.Delete(c.Request.Context(), attachment.ThumbnailKey)
+	}
+}
+
+// randomObjectName 生成随机的存储文件名
+func randomObjectName() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// sanitizeFileName 去掉路径并限制文件名长度
+func sanitizeFileName(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	if name == "." || name == "/" || name == "" {
+		name = "file"
+	}
+	if runes := []rune(name); len(runes) > 200 {
+		name = string(runes[:200])
+	}
+	return name
+}
@@ -0,0 +1,139 @@
+package controllers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xhy/blog-api/config"
+	"github.com/xhy/blog-api/models"
+	"gorm.io/gorm"
+)
+
+// 导出审计日志时每批读取的条数
+const auditExportBatchSize = 500
+
+// auditEvent 根据当前请求生成审计日志：执行者来自认证中间件，同时记录访问令牌、IP和请求ID
+// before为nil表示创建，after为nil表示删除
+func auditEvent(c *gin.Context, action, targetType string, targetID uint, before, after interface{}) models.AuditEvent {
+	event := models.AuditEvent{
+		ActorName:  c.GetString("username"),
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Changes:    models.AuditDiff(before, after),
+		IP:         c.ClientIP(),
+		RequestID:  c.GetString("requestID"),
+	}
+	if userID, exists := c.Get("userID"); exists {
+		id := userID.(uint)
+		event.ActorID = &id
+	}
+	if tokenID, exists := c.Get("tokenID"); exists {
+		id := tokenID.(uint)
+		event.TokenID = &id
+	}
+	return event
+}
+
+// recordAudit 在修改数据的事务中写入审计日志，与修改一起提交或回滚
+func recordAudit(c *gin.Context, tx *gorm.DB, action, targetType string, targetID uint, before, after interface{}) error {
+	event := auditEvent(c, action, targetType, targetID, before, after)
+	return tx.Create(&event).Error
+}
+
+// recordSystemAudit 以系统身份写入审计日志，用于举报达到阈值自动隐藏等由系统触发的修改
+// 不记录触发请求的用户和访问令牌，保留IP和请求ID便于追溯
+func recordSystemAudit(c *gin.Context, tx *gorm.DB, action, targetType string, targetID uint, before, after interface{}) error {
+	event := auditEvent(c, action, targetType, targetID, before, after)
+	event.ActorName = models.AuditSystem
+	event.ActorID = nil
+	event.TokenID = nil
+	return tx.Create(&event).Error
+}
+
+// GetAuditLog 获取审计日志，最新的在前，支持按执行者、操作、对象、请求ID和时间筛选（仅管理员）
+func GetAuditLog(c *gin.Context) {
+	var events []models.AuditEvent
+
+	query, ok := auditQuery(c)
+	if !ok {
+		return
+	}
+
+	// 分页参数
+	pageSize, offset := parsePagination(c)
+
+	if err := query.Order("id desc").Limit(pageSize).Offset(offset).Find(&events).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "获取审计日志失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Code:    http.StatusOK,
+		Message: "获取审计日志成功",
+		Data:    events,
+	})
+}
+
+// ExportAuditLog 按筛选条件导出审计日志，每行一个JSON对象，最早的在前（仅管理员）
+func ExportAuditLog(c *gin.Context) {
+	query, ok := auditQuery(c)
+	if !ok {
+		return
+	}
+
+	c.Header("Content-Type", "application/x-ndjson; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="audit-`+time.Now().Format("20060102-150405")+`.jsonl"`)
+	c.Status(http.StatusOK)
+
+	// 分批读取，避免一次性加载全部日志
+	encoder := json.NewEncoder(c.Writer)
+	var events []models.AuditEvent
+	err := query.WithContext(c.Request.Context()).Order("id").FindInBatches(&events, auditExportBatchSize, func(tx *gorm.DB, batch int) error {
+		for i := range events {
+			if err := encoder.Encode(&events[i]); err != nil {
+				return err
+			}
+		}
+		c.Writer.Flush()
+		return nil
+	}).Error
+	// 响应已经开始，出错时以一行error记录结束输出，让使用方能发现导出不完整
+	if err != nil {
+		log.Printf("导出审计日志失败: %v", err)
+		encoder.Encode(gin.H{"error": "导出中断: " + err.Error()})
+		c.Writer.Flush()
+	}
+}
+
+// auditQuery 校验管理员权限并根据查询参数生成审计日志查询，失败时写入响应
+func auditQuery(c *gin.Context) (*gorm.DB, bool) {
+	var filter models.AuditQuery
+
+	if _, ok := requireAdmin(c); !ok {
+		return nil, false
+	}
+
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数错误: " + err.Error(),
+		})
+		return nil, false
+	}
+	query, err := filter.Apply(config.DB.Model(&models.AuditEvent{}))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数错误: " + err.Error(),
+		})
+		return nil, false
+	}
+	return query, true
+}
@@ -0,0 +1,323 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xhy/blog-api/config"
+	"github.com/xhy/blog-api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AddBookmark 收藏文章，已收藏时移动到指定的收藏夹
+func AddBookmark(c *gin.Context) {
+	postID, ok := parseID(c, "id", c.Param("id"))
+	if !ok {
+		return
+	}
+	var input models.BookmarkInput
+	var post models.Post
+
+	// 获取当前用户ID
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.Response{
+			Code:    http.StatusUnauthorized,
+			Message: "未授权",
+		})
+		return
+	}
+
+	// 查询文章是否存在，被举报隐藏的文章只有作者可以收藏
+	err := config.DB.First(&post, postID).Error
+	if err == nil && !canViewPost(c, &post) {
+		err = gorm.ErrRecordNotFound
+	}
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, models.Response{
+				Code:    http.StatusNotFound,
+				Message: "文章不存在",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "获取文章失败: " + err.Error(),
+		})
+		return
+	}
+
+	// 请求体可以为空
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, models.Response{
+				Code:    http.StatusBadRequest,
+				Message: "请求参数错误: " + err.Error(),
+			})
+			return
+		}
+	}
+
+	// 收藏夹必须属于当前用户
+	if input.CollectionID != nil {
+		var collection models.BookmarkCollection
+		if err := config.DB.Where("user_id = ?", userID).First(&collection, *input.CollectionID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, models.Response{
+				Code:    http.StatusBadRequest,
+				Message: "收藏夹不存在",
+			})
+			return
+		}
+	}
+
+	bookmark := models.Bookmark{
+		UserID:       userID.(uint),
+		PostID:       post.ID,
+		CollectionID: input.CollectionID,
+	}
+	if err := config.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "post_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"collection_id", "updated_at"}),
+	}).Create(&bookmark).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "收藏失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Code:    http.StatusOK,
+		Message: "收藏成功",
+	})
+}
+
+// RemoveBookmark 取消收藏
+func RemoveBookmark(c *gin.Context) {
+	postID, ok := parseID(c, "id", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	// 获取当前用户ID
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.Response{
+			Code:    http.StatusUnauthorized,
+			Message: "未授权",
+		})
+		return
+	}
+
+	// 文章已删除时也允许取消收藏
+	if err := config.DB.Where("user_id = ? AND post_id = ?", userID, postID).Delete(&models.Bookmark{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "取消收藏失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Code:    http.StatusOK,
+		Message: "取消收藏成功",
+	})
+}
+
+// GetBookmarks 获取当前用户的收藏列表，可以按collection_id筛选收藏夹
+func GetBookmarks(c *gin.Context) {
+	var bookmarks []models.Bookmark
+
+	// 获取当前用户ID
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.Response{
+			Code:    http.StatusUnauthorized,
+			Message: "未授权",
+		})
+		return
+	}
+
+	// 分页参数
+	pageSize, offset := parsePagination(c)
+
+	query := config.DB.Where("user_id = ?", userID)
+	if collectionID := c.Query("collection_id"); collectionID != "" {
+		query = query.Where("collection_id = ?", collectionID)
+	}
+
+	// 同时加载已删除的文章，与被举报隐藏的文章一样标记为不可用
+	if err := query.
+		Preload("Post", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Preload("Post.User").
+		Order("created_at desc").Limit(pageSize).Offset(offset).
+		Find(&bookmarks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "获取收藏列表失败: " + err.Error(),
+		})
+		return
+	}
+
+	data := make([]models.BookmarkResponse, 0, len(bookmarks))
+	for i := range bookmarks {
+		b := &bookmarks[i]
+		item := models.BookmarkResponse{
+			ID:           b.ID,
+			PostID:       b.PostID,
+			CollectionID: b.CollectionID,
+			CreatedAt:    b.CreatedAt,
+			Available:    b.Post.ID != 0 && !b.Post.DeletedAt.Valid && canViewPost(c, &b.Post),
+		}
+		if item.Available {
+			b.Post.HideRendered()
+			item.Post = &b.Post
+		} else {
+			item.Notice = "该文章已不可用"
+		}
+		data = append(data, item)
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Code:    http.StatusOK,
+		Message: "获取收藏列表成功",
+		Data:    data,
+	})
+}
+
+// GetCollections 获取当前用户的收藏夹列表
+func GetCollections(c *gin.Context) {
+	var collections []models.BookmarkCollection
+
+	// 获取当前用户ID
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.Response{
+			Code:    http.StatusUnauthorized,
+			Message: "未授权",
+		})
+		return
+	}
+
+	if err := config.DB.Where("user_id = ?", userID).Order("name asc").Find(&collections).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "获取收藏夹列表失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Code:    http.StatusOK,
+		Message: "获取收藏夹列表成功",
+		Data:    collections,
+	})
+}
+
+// CreateCollection 创建收藏夹
+func CreateCollection(c *gin.Context) {
+	var input models.CollectionInput
+
+	// 获取当前用户ID
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.Response{
+			Code:    http.StatusUnauthorized,
+			Message: "未授权",
+		})
+		return
+	}
+
+	// 绑定请求数据
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	// 检查名称是否已存在
+	var existing models.BookmarkCollection
+	if err := config.DB.Where("user_id = ? AND name = ?", userID, input.Name).First(&existing).Error; err == nil {
+		c.JSON(http.StatusBadRequest, models.Response{
+			Code:    http.StatusBadRequest,
+			Message: "收藏夹已存在",
+		})
+		return
+	}
+
+	collection := models.BookmarkCollection{
+		UserID: userID.(uint),
+		Name:   input.Name,
+	}
+	if err := config.DB.Create(&collection).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "收藏夹创建失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.Response{
+		Code:    http.StatusCreated,
+		Message: "收藏夹创建成功",
+		Data:    collection,
+	})
+}
+
+// DeleteCollection 删除收藏夹，其中的收藏移回默认列表
+func DeleteCollection(c *gin.Context) {
+	id, ok := parseID(c, "id", c.Param("id"))
+	if !ok {
+		return
+	}
+	var collection models.BookmarkCollection
+
+	// 获取当前用户ID
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.Response{
+			Code:    http.StatusUnauthorized,
+			Message: "未授权",
+		})
+		return
+	}
+
+	if err := config.DB.Where("user_id = ?", userID).First(&collection, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, models.Response{
+				Code:    http.StatusNotFound,
+				Message: "收藏夹不存在",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "获取收藏夹失败: " + err.Error(),
+		})
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Bookmark{}).Where("collection_id = ?", collection.ID).
+			Update("collection_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&collection).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "删除收藏夹失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Code:    http.StatusOK,
+		Message: "删除收藏夹成功",
+	})
+}
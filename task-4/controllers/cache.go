@@ -0,0 +1,65 @@
+package controllers
+
+import (
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xhy/blog-api/cache"
+	"github.com/xhy/blog-api/config"
+)
+
+// 文章列表各分页共用一个版本号，文章变化时更换版本号让所有分页同时失效，
+// 旧版本的分页不再被读取，等待过期或被淘汰
+const postListVersionKey = "posts:list:version"
+
+// postCacheKey 文章详情（含作者和评论）的缓存key
+func postCacheKey(id uint) string {
+	return "post:" + strconv.FormatUint(uint64(id), 10)
+}
+
+// commentsCacheKey 文章评论列表的缓存key
+func commentsCacheKey(id uint) string {
+	return "post:" + strconv.FormatUint(uint64(id), 10) + ":comments"
+}
+
+// postListCacheKey 文章列表分页的缓存key
+func postListCacheKey(c *gin.Context, pageSize, offset int) string {
+	return "posts:list:" + postListVersion(c) + ":" + strconv.Itoa(pageSize) + ":" + strconv.Itoa(offset)
+}
+
+// postListVersion 读取文章列表的版本号，不存在时（首次使用或被淘汰）生成新版本号，
+// 保证不会读到淘汰前缓存的旧分页
+func postListVersion(c *gin.Context) string {
+	ctx := c.Request.Context()
+	if version, ok, err := config.Cache.Get(ctx, postListVersionKey); err == nil && ok {
+		return string(version)
+	}
+	return bumpPostListVersion(c)
+}
+
+// bumpPostListVersion 更换文章列表的版本号
+func bumpPostListVersion(c *gin.Context) string {
+	version := strconv.FormatInt(time.Now().UnixNano(), 36)
+	if err := config.Cache.Set(c.Request.Context(), postListVersionKey, []byte(version), 0); err != nil {
+		log.Printf("更新文章列表缓存版本失败: %v", err)
+	}
+	return version
+}
+
+// invalidatePostList 文章创建后让文章列表缓存失效
+func invalidatePostList(c *gin.Context) {
+	bumpPostListVersion(c)
+}
+
+// invalidatePost 文章或其评论变化后让文章详情、评论列表和文章列表缓存失效
+func invalidatePost(c *gin.Context, id uint) {
+	cache.Invalidate(c.Request.Context(), config.Cache, postCacheKey(id), commentsCacheKey(id))
+	bumpPostListVersion(c)
+}
+
+// cacheTTL 缓存过期时间
+func cacheTTL() time.Duration {
+	return config.GetConfig().Cache.TTL
+}
@@ -2,17 +2,27 @@ package controllers
 
 import (
 	"net/http"
+	"sort"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/xhy/blog-api/cache"
 	"github.com/xhy/blog-api/config"
+	"github.com/xhy/blog-api/filter"
 	"github.com/xhy/blog-api/models"
+	"github.com/xhy/blog-api/notify"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // CreateComment 创建评论
 func CreateComment(c *gin.Context) {
 	var input models.CommentInput
-	postID := c.Param("id")
+	postID, ok := parseID(c, "id", c.Param("id"))
+	if !ok {
+		return
+	}
 	var post models.Post
 
 	// 获取当前用户ID
@@ -25,8 +35,8 @@ func CreateComment(c *gin.Context) {
 		return
 	}
 
-	// 查询文章是否存在
-	if err := config.DB.First(&post, postID).Error; err != nil {
+	// 查询文章是否存在，被举报隐藏的文章不能评论
+	if err := config.DB.Scopes(models.VisiblePosts).First(&post, postID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, models.Response{
 				Code:    http.StatusNotFound,
@@ -50,14 +60,64 @@ func CreateComment(c *gin.Context) {
 		return
 	}
 
+	// 回复的评论必须属于同一篇文章且已经发布
+	var parent models.Comment
+	if input.ParentID != nil {
+		if err := config.DB.Where("post_id = ? AND status = ?", post.ID, models.CommentApproved).First(&parent, *input.ParentID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, models.Response{
+				Code:    http.StatusBadRequest,
+				Message: "回复的评论不存在",
+			})
+			return
+		}
+	}
+
+	// 检查垃圾内容
+	verdict, ok := filterContent(c, &filter.Content{Kind: filter.KindComment, UserID: userID.(uint), Text: input.Content})
+	if !ok {
+		return
+	}
+
+	// 根据审核策略决定评论直接发布还是等待审核，被内容过滤器拦下的评论总是等待审核
+	status, err := initialCommentStatus(&post, userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "获取审核策略失败: " + err.Error(),
+		})
+		return
+	}
+	if verdict.Action == filter.Hold {
+		status = models.CommentPending
+	}
+
 	// 创建评论
 	comment := models.Comment{
-		Content: input.Content,
-		UserID:  userID.(uint),
-		PostID:  post.ID,
+		Content:      input.Content,
+		UserID:       userID.(uint),
+		PostID:       post.ID,
+		ParentID:     input.ParentID,
+		Status:       status,
+		FilterReason: verdict.Reason,
 	}
 
-	if err := config.DB.Create(&comment).Error; err != nil {
+	// 锁定文章后再创建评论，防止文章同时被删除，评论遗留在回收站中的文章下
+	if err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&models.Post{}, post.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&comment).Error; err != nil {
+			return err
+		}
+		return recordAudit(c, tx, models.AuditCreate, models.TargetComment, comment.ID, nil, &comment)
+	}); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, models.Response{
+				Code:    http.StatusNotFound,
+				Message: "文章不存在",
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, models.Response{
 			Code:    http.StatusInternalServerError,
 			Message: "评论创建失败: " + err.Error(),
@@ -65,6 +125,19 @@ func CreateComment(c *gin.Context) {
 		return
 	}
 
+	// 等待审核的评论只有评论者本人可见，审核通过后再更新缓存和发送通知
+	if comment.Status != models.CommentApproved {
+		c.JSON(http.StatusCreated, models.Response{
+			Code:    http.StatusCreated,
+			Message: "评论已提交，等待审核",
+			Data:    comment,
+		})
+		return
+	}
+
+	invalidatePost(c, post.ID)
+	notifyComment(&comment, &post, &parent)
+
 	c.JSON(http.StatusCreated, models.Response{
 		Code:    http.StatusCreated,
 		Message: "评论创建成功",
@@ -72,14 +145,102 @@ func CreateComment(c *gin.Context) {
 	})
 }
 
+// initialCommentStatus 根据文章或全站的审核策略决定新评论的状态
+// 文章作者和版主的评论总是直接发布
+func initialCommentStatus(post *models.Post, userID uint) (string, error) {
+	if userID == post.UserID {
+		return models.CommentApproved, nil
+	}
+
+	var user models.User
+	if err := config.DB.Select("id", "role").First(&user, userID).Error; err != nil {
+		return "", err
+	}
+	if user.IsModerator() {
+		return models.CommentApproved, nil
+	}
+
+	policy := post.Moderation
+	if policy == "" {
+		policy = config.GetConfig().Moderation.Policy
+	}
+
+	switch policy {
+	case models.ModerationAll:
+		return models.CommentPending, nil
+	case models.ModerationTrusted:
+		trusted, err := isTrustedCommenter(userID)
+		if err != nil || !trusted {
+			return models.CommentPending, err
+		}
+	}
+	return models.CommentApproved, nil
+}
+
+// isTrustedCommenter 已发布的评论达到一定数量且从未被标记为垃圾评论的用户受信任
+func isTrustedCommenter(userID uint) (bool, error) {
+	var counts struct {
+		Approved int64
+		Spam     int64
+	}
+	if err := config.DB.Model(&models.Comment{}).
+		Select("COUNT(CASE WHEN status = ? THEN 1 END) AS approved, COUNT(CASE WHEN status = ? THEN 1 END) AS spam",
+			models.CommentApproved, models.CommentSpam).
+		Where("user_id = ?", userID).
+		Scan(&counts).Error; err != nil {
+		return false, err
+	}
+	return counts.Spam == 0 && counts.Approved >= int64(config.GetConfig().Moderation.TrustedMinApproved), nil
+}
+
+// notifyComment 评论发布后通知被回复的评论作者和文章作者，同一个人只通知一次
+// parent为空结构体表示评论不是回复
+func notifyComment(comment *models.Comment, post *models.Post, parent *models.Comment) {
+	if comment.ParentID != nil {
+		notify.Send(models.Notification{
+			RecipientID: parent.UserID,
+			ActorID:     comment.UserID,
+			Type:        models.NotificationReply,
+			PostID:      post.ID,
+			CommentID:   &comment.ID,
+		})
+	}
+	if comment.ParentID == nil || parent.UserID != post.UserID {
+		notify.Send(models.Notification{
+			RecipientID: post.UserID,
+			ActorID:     comment.UserID,
+			Type:        models.NotificationComment,
+			PostID:      post.ID,
+			CommentID:   &comment.ID,
+		})
+	}
+}
+
 // GetComments 获取文章的所有评论
 func GetComments(c *gin.Context) {
-	postID := c.Param("id")
-	var post models.Post
 	var comments []models.Comment
 
-	// 查询文章是否存在
-	if err := config.DB.First(&post, postID).Error; err != nil {
+	postID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.Response{
+			Code:    http.StatusNotFound,
+			Message: "文章不存在",
+		})
+		return
+	}
+
+	// 查询文章是否存在以及评论列表
+	err = cache.GetOrLoad(c.Request.Context(), config.Cache, commentsCacheKey(uint(postID)), cacheTTL(), &comments, func() (interface{}, error) {
+		var post models.Post
+		var comments []models.Comment
+		if err := config.DB.Scopes(models.VisiblePosts).First(&post, postID).Error; err != nil {
+			return nil, err
+		}
+		err := config.DB.Where("post_id = ? AND status = ?", postID, models.CommentApproved).
+			Preload("User").Order("created_at desc").Find(&comments).Error
+		return comments, err
+	})
+	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, models.Response{
 				Code:    http.StatusNotFound,
@@ -89,23 +250,57 @@ func GetComments(c *gin.Context) {
 		}
 		c.JSON(http.StatusInternalServerError, models.Response{
 			Code:    http.StatusInternalServerError,
-			Message: "获取文章失败: " + err.Error(),
+			Message: "获取评论列表失败: " + err.Error(),
 		})
 		return
 	}
 
-	// 查询评论列表
-	if err := config.DB.Where("post_id = ?", postID).Preload("User").Order("created_at desc").Find(&comments).Error; err != nil {
+	// 等待审核的评论只有评论者本人可见，不进入共享的缓存
+	if userID := optionalUserID(c); userID != 0 {
+		var pending []models.Comment
+		if err := config.DB.Where("post_id = ? AND user_id = ? AND status = ?", postID, userID, models.CommentPending).
+			Preload("User").Find(&pending).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, models.Response{
+				Code:    http.StatusInternalServerError,
+				Message: "获取评论列表失败: " + err.Error(),
+			})
+			return
+		}
+		if len(pending) > 0 {
+			comments = append(comments, pending...)
+			sort.SliceStable(comments, func(i, j int) bool {
+				return comments[i].CreatedAt.After(comments[j].CreatedAt)
+			})
+			c.Header("Cache-Control", "private")
+		}
+	}
+
+	// 填充表态统计，表态是响应的一部分，需要计入ETag
+	if err := fillCommentReactions(c, comments); err != nil {
 		c.JSON(http.StatusInternalServerError, models.Response{
 			Code:    http.StatusInternalServerError,
-			Message: "获取评论列表失败: " + err.Error(),
+			Message: "获取表态统计失败: " + err.Error(),
 		})
 		return
 	}
 
+	// 评论和表态未变化时返回304，表态中包含当前用户是否已表态，响应随认证信息变化
+	// 取消表态不留下修改时间，只按ETag判断，不返回Last-Modified
+	c.Header("Vary", "Authorization")
+	if checkNotModified(c, commentListETag(comments), time.Time{}) {
+		return
+	}
+
+	// 兼容渲染功能上线前保存的评论
+	for i := range comments {
+		if comments[i].ContentHTML == "" {
+			comments[i].Render()
+		}
+	}
+
 	c.JSON(http.StatusOK, models.Response{
 		Code:    http.StatusOK,
 		Message: "获取评论列表成功",
 		Data:    comments,
 	})
-} 
\ No newline at end of file
+}
@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xhy/blog-api/models"
+)
+
+// checkNotModified 写入ETag和Last-Modified响应头，客户端缓存仍然有效时返回304
+// If-None-Match优先于If-Modified-Since
+func checkNotModified(c *gin.Context, etag string, lastModified time.Time) bool {
+	c.Header("ETag", etag)
+	if !lastModified.IsZero() {
+		c.Header("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if inm := c.GetHeader("If-None-Match"); inm != "" {
+		if !etagMatch(inm, etag) {
+			return false
+		}
+		c.Status(http.StatusNotModified)
+		return true
+	}
+
+	if ims := c.GetHeader("If-Modified-Since"); ims != "" && !lastModified.IsZero() {
+		since, err := http.ParseTime(ims)
+		if err != nil || lastModified.Truncate(time.Second).After(since) {
+			return false
+		}
+		c.Status(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+// etagMatch 判断If-None-Match列表中是否包含指定ETag，按弱比较处理
+func etagMatch(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// etagMatchStrong 判断If-Match列表中是否包含指定ETag，按强比较处理，弱ETag不会匹配
+func etagMatchStrong(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || (!strings.HasPrefix(candidate, "W/") && candidate == etag) {
+			return true
+		}
+	}
+	return false
+}
+
+// weakETag 根据资源的版本信息生成弱ETag
+// 版本信息相同时响应在语义上相同，阅读量的变化不会让ETag改变
+func weakETag(versions ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(versions, "|")))
+	return `W/"` + hex.EncodeToString(sum[:12]) + `"`
+}
+
+// postETag 文章详情的ETag，文章内容、作者、评论或表态变化时改变
+// 表态中包含当前用户是否已表态，调用前需要填充文章和评论的表态统计
+func postETag(post *models.Post) string {
+	versions := []string{"post", uintString(post.ID), uintString(post.UserID), strconv.FormatInt(post.UpdatedAt.UnixNano(), 10), strconv.FormatInt(post.CommentCount, 10)}
+	versions = append(versions, reactionVersions(post.Reactions)...)
+	return weakETag(append(versions, commentVersions(post.Comments)...)...)
+}
+
+// postVersionETag 文章本身的强ETag，只取决于文章的修改时间，用于更新文章时的If-Match校验
+// 新评论、阅读量等变化不会改变它，作者编辑期间有人评论不会导致更新失败
+func postVersionETag(post *models.Post) string {
+	sum := sha256.Sum256([]byte("post-version|" + uintString(post.ID) + "|" + strconv.FormatInt(post.UpdatedAt.UnixNano(), 10)))
+	return `"` + hex.EncodeToString(sum[:12]) + `"`
+}
+
+// postListETag 文章列表分页的ETag，调用前需要填充表态统计
+func postListETag(posts []models.Post) string {
+	versions := []string{"posts"}
+	for i := range posts {
+		versions = append(versions, uintString(posts[i].ID), uintString(posts[i].UserID), strconv.FormatInt(posts[i].UpdatedAt.UnixNano(), 10), strconv.FormatInt(posts[i].CommentCount, 10))
+		versions = append(versions, reactionVersions(posts[i].Reactions)...)
+	}
+	return weakETag(versions...)
+}
+
+// commentListETag 评论列表的ETag，调用前需要填充表态统计
+func commentListETag(comments []models.Comment) string {
+	return weakETag(append([]string{"comments"}, commentVersions(comments)...)...)
+}
+
+// commentVersions 评论的版本信息：ID、作者、修改时间和表态
+// 删除用户时评论转给其他用户不会修改updated_at，作者需要单独计入
+func commentVersions(comments []models.Comment) []string {
+	versions := make([]string, 0, len(comments)*3)
+	for i := range comments {
+		versions = append(versions, uintString(comments[i].ID), uintString(comments[i].UserID), strconv.FormatInt(comments[i].UpdatedAt.UnixNano(), 10))
+		versions = append(versions, reactionVersions(comments[i].Reactions)...)
+	}
+	return versions
+}
+
+// reactionVersions 表态统计的版本信息，包括每个表情的数量和当前用户是否已表态
+func reactionVersions(summaries []models.ReactionSummary) []string {
+	versions := make([]string, 0, len(summaries)+1)
+	versions = append(versions, "reactions")
+	for _, r := range summaries {
+		versions = append(versions, r.Emoji+":"+strconv.FormatInt(r.Count, 10)+":"+strconv.FormatBool(r.Reacted))
+	}
+	return versions
+}
+
+// uintString 将ID格式化为字符串
+func uintString(id uint) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
@@ -0,0 +1,156 @@
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"html"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/feeds"
+	"github.com/xhy/blog-api/config"
+	"github.com/xhy/blog-api/markdown"
+	"github.com/xhy/blog-api/models"
+)
+
+// 订阅源中摘要的最大字符数
+const feedExcerptLength = 200
+
+// GetRSSFeed 全站最新文章的RSS 2.0订阅源
+func GetRSSFeed(c *gin.Context) {
+	feed, ok := buildFeed(c, nil)
+	if !ok {
+		return
+	}
+	writeFeed(c, feed, "application/rss+xml", (*feeds.Feed).ToRss)
+}
+
+// GetAtomFeed 全站最新文章的Atom订阅源
+func GetAtomFeed(c *gin.Context) {
+	feed, ok := buildFeed(c, nil)
+	if !ok {
+		return
+	}
+	writeFeed(c, feed, "application/atom+xml", (*feeds.Feed).ToAtom)
+}
+
+// GetUserRSSFeed 单个作者最新文章的RSS 2.0订阅源
+func GetUserRSSFeed(c *gin.Context) {
+	var user models.User
+	if !findUser(c, &user) {
+		return
+	}
+
+	feed, ok := buildFeed(c, &user)
+	if !ok {
+		return
+	}
+	writeFeed(c, feed, "application/rss+xml", (*feeds.Feed).ToRss)
+}
+
+// buildFeed 查询最新文章生成订阅源，author不为nil时只包含该作者的文章
+func buildFeed(c *gin.Context, author *models.User) (*feeds.Feed, bool) {
+	site := config.GetConfig().Site
+	var posts []models.Post
+
+	query := config.DB.Scopes(models.VisiblePosts).Preload("User").Order("created_at desc").Limit(site.FeedSize)
+	if author != nil {
+		query = query.Where("user_id = ?", author.ID)
+	}
+	if err := query.Find(&posts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "获取文章列表失败: " + err.Error(),
+		})
+		return nil, false
+	}
+
+	feed := &feeds.Feed{
+		Title:       site.Title,
+		Link:        &feeds.Link{Href: site.URL},
+		Description: site.Description,
+	}
+	if author != nil {
+		feed.Title = author.Username + " - " + site.Title
+		feed.Link = &feeds.Link{Href: userURL(author.ID)}
+		feed.Description = author.Username + "的最新文章"
+		feed.Created = author.CreatedAt
+	}
+
+	for i := range posts {
+		post := &posts[i]
+
+		// 兼容渲染功能上线前保存的文章
+		if post.ContentHTML == "" {
+			post.Render()
+		}
+
+		item := &feeds.Item{
+			Title:       post.Title,
+			Link:        &feeds.Link{Href: postURL(post)},
+			Author:      &feeds.Author{Name: post.User.Username},
+			Id:          postGUID(post),
+			IsPermaLink: "false",
+			Created:     post.CreatedAt,
+			Updated:     post.UpdatedAt,
+			Description: html.EscapeString(markdown.Excerpt(post.ContentHTML, feedExcerptLength)),
+		}
+		if site.FeedFullContent {
+			item.Content = post.ContentHTML
+		}
+		feed.Add(item)
+
+		// 订阅源的更新时间取最近一次修改的文章
+		if post.UpdatedAt.After(feed.Updated) {
+			feed.Updated = post.UpdatedAt
+		}
+	}
+
+	return feed, true
+}
+
+// writeFeed 序列化订阅源并支持条件请求
+func writeFeed(c *gin.Context, feed *feeds.Feed, contentType string, encode func(*feeds.Feed) (string, error)) {
+	body, err := encode(feed)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "生成订阅源失败: " + err.Error(),
+		})
+		return
+	}
+
+	// 文章删除不会改变最近修改时间，ETag根据内容计算
+	sum := sha256.Sum256([]byte(body))
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	c.Header("Cache-Control", "public, max-age=300")
+	if checkNotModified(c, etag, feed.Updated) {
+		return
+	}
+	c.Data(http.StatusOK, contentType+"; charset=utf-8", []byte(body))
+}
+
+// postURL 文章的对外访问地址，有别名时使用别名
+func postURL(post *models.Post) string {
+	if post.Slug != "" {
+		return config.GetConfig().Site.URL + "/api/posts/by-slug/" + url.PathEscape(post.Slug)
+	}
+	return config.GetConfig().Site.URL + "/api/posts/" + strconv.FormatUint(uint64(post.ID), 10)
+}
+
+// userURL 用户主页的对外访问地址
+func userURL(id uint) string {
+	return config.GetConfig().Site.URL + "/api/users/" + strconv.FormatUint(uint64(id), 10)
+}
+
+// postGUID 文章在订阅源中的唯一标识，使用tag URI，不随访问地址变化
+func postGUID(post *models.Post) string {
+	host := config.GetConfig().Site.URL
+	if u, err := url.Parse(host); err == nil && u.Hostname() != "" {
+		host = u.Hostname()
+	}
+	return "tag:" + host + "," + post.CreatedAt.Format("2006-01-02") + ":post-" + strconv.FormatUint(uint64(post.ID), 10)
+}
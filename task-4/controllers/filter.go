@@ -0,0 +1,45 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xhy/blog-api/config"
+	"github.com/xhy/blog-api/filter"
+	"github.com/xhy/blog-api/models"
+)
+
+// filterContent 发布前用内容过滤器检查文章或评论，版主的内容不检查
+// 被拒绝时写入422响应；文章没有审核队列，需要审核的文章同样无法发布
+func filterContent(c *gin.Context, content *filter.Content) (filter.Verdict, bool) {
+	var user models.User
+	if err := config.DB.Select("id", "role").First(&user, content.UserID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "获取用户信息失败: " + err.Error(),
+		})
+		return filter.Verdict{}, false
+	}
+	if user.IsModerator() {
+		return filter.Verdict{Action: filter.Allow}, true
+	}
+
+	verdict, err := filter.Default.Check(c.Request.Context(), content)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "内容检查失败: " + err.Error(),
+		})
+		return verdict, false
+	}
+
+	if verdict.Action == filter.Reject || (verdict.Action == filter.Hold && content.Kind == filter.KindPost) {
+		c.JSON(http.StatusUnprocessableEntity, models.Response{
+			Code:    http.StatusUnprocessableEntity,
+			Message: "内容未通过检查: " + verdict.Reason,
+			Data:    verdict,
+		})
+		return verdict, false
+	}
+	return verdict, true
+}
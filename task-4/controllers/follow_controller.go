@@ -0,0 +1,263 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xhy/blog-api/config"
+	"github.com/xhy/blog-api/models"
+	"github.com/xhy/blog-api/views"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FollowUser 关注用户
+func FollowUser(c *gin.Context) {
+	var user models.User
+
+	// 获取当前用户ID
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.Response{
+			Code:    http.StatusUnauthorized,
+			Message: "未授权",
+		})
+		return
+	}
+
+	if !findUser(c, &user) {
+		return
+	}
+
+	if user.ID == userID.(uint) {
+		c.JSON(http.StatusBadRequest, models.Response{
+			Code:    http.StatusBadRequest,
+			Message: "不能关注自己",
+		})
+		return
+	}
+
+	follow := models.Follow{
+		FollowerID: userID.(uint),
+		FolloweeID: user.ID,
+	}
+	if err := config.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&follow).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "关注失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Code:    http.StatusOK,
+		Message: "关注成功",
+	})
+}
+
+// UnfollowUser 取消关注
+func UnfollowUser(c *gin.Context) {
+	id, ok := parseID(c, "id", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	// 获取当前用户ID
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.Response{
+			Code:    http.StatusUnauthorized,
+			Message: "未授权",
+		})
+		return
+	}
+
+	if err := config.DB.Where("follower_id = ? AND followee_id = ?", userID, id).Delete(&models.Follow{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "取消关注失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Code:    http.StatusOK,
+		Message: "取消关注成功",
+	})
+}
+
+// GetUserProfile 获取用户主页信息
+func GetUserProfile(c *gin.Context) {
+	var user models.User
+	if !findUser(c, &user) {
+		return
+	}
+
+	profile := models.UserProfile{
+		ID:        user.ID,
+		Username:  user.Username,
+		CreatedAt: user.CreatedAt,
+		PostCount: user.PostCount,
+	}
+
+	err := config.DB.Model(&models.Follow{}).Where("followee_id = ?", user.ID).Count(&profile.FollowerCount).Error
+	if err == nil {
+		err = config.DB.Model(&models.Follow{}).Where("follower_id = ?", user.ID).Count(&profile.FollowingCount).Error
+	}
+	if viewerID := optionalUserID(c); err == nil && viewerID != 0 {
+		var count int64
+		err = config.DB.Model(&models.Follow{}).Where("follower_id = ? AND followee_id = ?", viewerID, user.ID).Count(&count).Error
+		profile.Following = count > 0
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "获取用户信息失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Code:    http.StatusOK,
+		Message: "获取用户信息成功",
+		Data:    profile,
+	})
+}
+
+// GetFollowers 获取用户的粉丝列表
+func GetFollowers(c *gin.Context) {
+	listFollows(c, "followee_id", "follower_id", "获取粉丝列表")
+}
+
+// GetFollowing 获取用户的关注列表
+func GetFollowing(c *gin.Context) {
+	listFollows(c, "follower_id", "followee_id", "获取关注列表")
+}
+
+// listFollows 按关注时间倒序分页列出关注关系另一端的用户
+func listFollows(c *gin.Context, matchColumn, userColumn, action string) {
+	var user models.User
+	if !findUser(c, &user) {
+		return
+	}
+
+	// 分页参数
+	pageSize, offset := parsePagination(c)
+
+	users := []models.UserBrief{}
+	if err := config.DB.Model(&models.Follow{}).
+		Select("users.id, users.username").
+		Joins("JOIN users ON users.id = follows."+userColumn+" AND users.deleted_at IS NULL").
+		Where("follows."+matchColumn+" = ?", user.ID).
+		Order("follows.created_at desc").Limit(pageSize).Offset(offset).
+		Scan(&users).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: action + "失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Code:    http.StatusOK,
+		Message: action + "成功",
+		Data:    users,
+	})
+}
+
+// GetFeed 获取关注作者的文章动态，按发布时间倒序，使用游标分页
+// 自增ID与发布时间顺序一致，游标为上一页最后一篇文章的ID。
+// 查询按主键倒序扫描文章并通过关注关系唯一索引判断作者是否被关注，
+// 关注的作者很多时也只需扫描到凑满一页为止，不需要把所有作者的文章取出来排序
+func GetFeed(c *gin.Context) {
+	var posts []models.Post
+
+	// 获取当前用户ID
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.Response{
+			Code:    http.StatusUnauthorized,
+			Message: "未授权",
+		})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	followees := config.DB.Model(&models.Follow{}).Select("followee_id").Where("follower_id = ?", userID)
+	query := config.DB.Scopes(models.VisiblePosts).Where("user_id IN (?)", followees)
+	if cursor := c.Query("cursor"); cursor != "" {
+		before, err := strconv.ParseUint(cursor, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.Response{
+				Code:    http.StatusBadRequest,
+				Message: "无效的游标",
+			})
+			return
+		}
+		query = query.Where("id < ?", before)
+	}
+
+	// 多取一条用于判断是否还有下一页
+	if err := query.Preload("User").Order("id desc").Limit(limit + 1).Find(&posts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "获取动态失败: " + err.Error(),
+		})
+		return
+	}
+
+	feed := models.FeedResponse{Posts: posts}
+	if len(posts) > limit {
+		feed.Posts = posts[:limit]
+		feed.NextCursor = strconv.FormatUint(uint64(feed.Posts[limit-1].ID), 10)
+	}
+
+	// 列表只返回Markdown源文
+	for i := range feed.Posts {
+		feed.Posts[i].HideRendered()
+		feed.Posts[i].ViewCount += views.Default.Pending(feed.Posts[i].ID)
+	}
+
+	// 填充表态统计
+	if err := fillPostReactions(c, feed.Posts); err != nil {
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "获取表态统计失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Code:    http.StatusOK,
+		Message: "获取动态成功",
+		Data:    feed,
+	})
+}
+
+// findUser 根据路径参数id查询用户，id无效时写入400响应，不存在时写入404响应
+func findUser(c *gin.Context, user *models.User) bool {
+	id, ok := parseID(c, "id", c.Param("id"))
+	if !ok {
+		return false
+	}
+	if err := config.DB.First(user, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, models.Response{
+				Code:    http.StatusNotFound,
+				Message: "用户不存在",
+			})
+			return false
+		}
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "获取用户失败: " + err.Error(),
+		})
+		return false
+	}
+	return true
+}
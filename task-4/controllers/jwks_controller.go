@@ -0,0 +1,15 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xhy/blog-api/utils"
+)
+
+// GetJWKS 公布JWT验证公钥
+// 按RFC 7517返回原始JWK Set，不使用通用响应结构，方便其他服务直接使用
+func GetJWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, utils.JWKS())
+}
@@ -0,0 +1,216 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xhy/blog-api/config"
+	"github.com/xhy/blog-api/filter"
+	"github.com/xhy/blog-api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 审核操作对应的评论状态
+var moderationActions = map[string]string{
+	"approve": models.CommentApproved,
+	"reject":  models.CommentRejected,
+	"spam":    models.CommentSpam,
+}
+
+// GetModerationQueue 获取评论审核队列，默认只返回等待审核的评论，最早提交的在前
+// 版主可以看到所有文章的评论，其他用户只能看到自己文章下的评论，不包括被举报自动隐藏的评论
+func GetModerationQueue(c *gin.Context) {
+	var comments []models.Comment
+
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	status := c.DefaultQuery("status", models.CommentPending)
+	if !models.IsCommentStatus(status) {
+		c.JSON(http.StatusBadRequest, models.Response{
+			Code:    http.StatusBadRequest,
+			Message: "不支持的评论状态: " + status,
+		})
+		return
+	}
+
+	// 分页参数
+	pageSize, offset := parsePagination(c)
+
+	// 回收站中文章的评论随文章一起删除，不进入审核队列
+	query := config.DB.Joins("JOIN posts ON posts.id = comments.post_id AND posts.deleted_at IS NULL").
+		Where("comments.status = ?", status)
+	if !user.IsModerator() {
+		query = query.Where("posts.user_id = ?", user.ID).Scopes(models.ExcludeReportHidden)
+	}
+	if postID := c.Query("post_id"); postID != "" {
+		query = query.Where("comments.post_id = ?", postID)
+	}
+
+	if err := query.
+		Preload("User").
+		Preload("Post", func(db *gorm.DB) *gorm.DB { return db.Select("id", "title", "slug", "user_id", "moderation") }).
+		Order("comments.created_at asc").Limit(pageSize).Offset(offset).
+		Find(&comments).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "获取审核队列失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Code:    http.StatusOK,
+		Message: "获取审核队列成功",
+		Data:    comments,
+	})
+}
+
+// ModerateComments 批量审核评论：发布、拒绝或标记为垃圾评论
+// 版主可以审核所有评论，文章作者只能审核自己文章下的评论，没有权限的评论被跳过
+// 被举报自动隐藏的评论只能由版主处理，避免作者绕过举报恢复评论
+func ModerateComments(c *gin.Context) {
+	var input models.ModerationInput
+
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	// 绑定请求数据
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+	status := moderationActions[input.Action]
+
+	result := models.ModerationResult{Updated: []uint{}, Skipped: []uint{}}
+	var published []models.Comment
+	posts := make(map[uint]models.Post)
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var comments []models.Comment
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Preload("Post").
+			Where("id IN ?", input.IDs).Order("id").Find(&comments).Error; err != nil {
+			return err
+		}
+
+		found := make(map[uint]bool, len(comments))
+		for i := range comments {
+			comment := &comments[i]
+			found[comment.ID] = true
+
+			// 所属文章在回收站中时Post为空，评论随文章一起删除，不再审核
+			if comment.Post.ID == 0 || (!user.IsModerator() && (comment.Post.UserID != user.ID || comment.ReportHidden())) {
+				result.Skipped = append(result.Skipped, comment.ID)
+				continue
+			}
+
+			previous := comment.Status
+			changed, err := moderateAudited(c, tx, comment, status, user.ID)
+			if err != nil {
+				return err
+			}
+			if !changed {
+				result.Skipped = append(result.Skipped, comment.ID)
+				continue
+			}
+			// 用审核结果训练垃圾内容分类器
+			if err := filter.Learn(tx, comment, status); err != nil {
+				return err
+			}
+
+			result.Updated = append(result.Updated, comment.ID)
+			posts[comment.PostID] = comment.Post
+			// 首次审核通过时才发送通知，撤下后重新发布不再重复通知
+			if status == models.CommentApproved && previous == models.CommentPending {
+				published = append(published, *comment)
+			}
+		}
+
+		for _, id := range input.IDs {
+			if !found[id] {
+				result.Skipped = append(result.Skipped, id)
+				found[id] = true
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "审核评论失败: " + err.Error(),
+		})
+		return
+	}
+
+	for id := range posts {
+		invalidatePost(c, id)
+	}
+	for i := range published {
+		comment := &published[i]
+		post := posts[comment.PostID]
+		var parent models.Comment
+		if comment.ParentID != nil {
+			config.DB.Select("id", "user_id").First(&parent, *comment.ParentID)
+		}
+		notifyComment(comment, &post, &parent)
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Code:    http.StatusOK,
+		Message: "审核评论成功",
+		Data:    result,
+	})
+}
+
+// moderateAudited 修改评论的审核状态，状态发生变化时写入审计日志
+func moderateAudited(c *gin.Context, tx *gorm.DB, comment *models.Comment, status string, moderatorID uint) (bool, error) {
+	before := *comment
+	changed, err := comment.Moderate(tx, status, moderatorID)
+	if err != nil || !changed {
+		return changed, err
+	}
+	// 没有处理人表示由系统自动处理
+	if moderatorID == 0 {
+		return true, recordSystemAudit(c, tx, models.AuditUpdate, models.TargetComment, comment.ID, &before, comment)
+	}
+	return true, recordAudit(c, tx, models.AuditUpdate, models.TargetComment, comment.ID, &before, comment)
+}
+
+// currentUser 查询当前登录的用户，角色等信息以数据库为准，失败时写入响应
+func currentUser(c *gin.Context) (*models.User, bool) {
+	// 获取当前用户ID
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.Response{
+			Code:    http.StatusUnauthorized,
+			Message: "未授权",
+		})
+		return nil, false
+	}
+
+	var user models.User
+	if err := config.DB.First(&user, userID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusUnauthorized, models.Response{
+				Code:    http.StatusUnauthorized,
+				Message: "用户不存在",
+			})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "获取用户信息失败: " + err.Error(),
+		})
+		return nil, false
+	}
+	return &user, true
+}
@@ -0,0 +1,240 @@
+package controllers
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	"github.com/xhy/blog-api/config"
+	"github.com/xhy/blog-api/models"
+	"github.com/xhy/blog-api/notify"
+	"gorm.io/gorm"
+)
+
+// 实时推送连接的心跳间隔，防止代理关闭空闲连接
+const streamHeartbeat = 25 * time.Second
+
+// 断线重连时每批补发的通知数量
+const streamReplayBatch = 100
+
+// GetNotifications 获取当前用户的通知列表，unread=true时只返回未读通知
+func GetNotifications(c *gin.Context) {
+	var resp models.NotificationListResponse
+
+	// 获取当前用户ID
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.Response{
+			Code:    http.StatusUnauthorized,
+			Message: "未授权",
+		})
+		return
+	}
+
+	// 分页参数
+	pageSize, offset := parsePagination(c)
+
+	query := config.DB.Where("recipient_id = ?", userID)
+	if c.Query("unread") == "true" {
+		query = query.Where("read_at IS NULL")
+	}
+
+	if err := query.Preload("Actor").Order("id desc").Limit(pageSize).Offset(offset).Find(&resp.Notifications).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "获取通知列表失败: " + err.Error(),
+		})
+		return
+	}
+
+	if err := config.DB.Model(&models.Notification{}).Where("recipient_id = ? AND read_at IS NULL", userID).Count(&resp.UnreadCount).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "获取未读数量失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Code:    http.StatusOK,
+		Message: "获取通知列表成功",
+		Data:    resp,
+	})
+}
+
+// MarkNotificationRead 将通知标记为已读
+func MarkNotificationRead(c *gin.Context) {
+	id, ok := parseID(c, "id", c.Param("id"))
+	if !ok {
+		return
+	}
+	var notification models.Notification
+
+	// 获取当前用户ID
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.Response{
+			Code:    http.StatusUnauthorized,
+			Message: "未授权",
+		})
+		return
+	}
+
+	// 只能操作自己的通知，他人的通知按不存在处理
+	if err := config.DB.Where("recipient_id = ?", userID).First(&notification, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, models.Response{
+				Code:    http.StatusNotFound,
+				Message: "通知不存在",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "获取通知失败: " + err.Error(),
+		})
+		return
+	}
+
+	if notification.ReadAt == nil {
+		now := time.Now()
+		if err := config.DB.Model(&notification).Update("read_at", now).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, models.Response{
+				Code:    http.StatusInternalServerError,
+				Message: "标记已读失败: " + err.Error(),
+			})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Code:    http.StatusOK,
+		Message: "标记已读成功",
+	})
+}
+
+// MarkAllNotificationsRead 将全部通知标记为已读
+func MarkAllNotificationsRead(c *gin.Context) {
+	// 获取当前用户ID
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.Response{
+			Code:    http.StatusUnauthorized,
+			Message: "未授权",
+		})
+		return
+	}
+
+	if err := config.DB.Model(&models.Notification{}).
+		Where("recipient_id = ? AND read_at IS NULL", userID).
+		Update("read_at", time.Now()).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "标记已读失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Code:    http.StatusOK,
+		Message: "全部标记已读成功",
+	})
+}
+
+// StreamNotifications 通过Server-Sent Events实时推送通知
+// 事件ID为通知ID，客户端断线重连时浏览器会带上Last-Event-ID，先补发断线期间的通知
+func StreamNotifications(c *gin.Context) {
+	// 获取当前用户ID
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.Response{
+			Code:    http.StatusUnauthorized,
+			Message: "未授权",
+		})
+		return
+	}
+
+	// 先订阅再补发，避免两步之间产生的通知丢失
+	ch, cancel := notify.Default.Subscribe(userID.(uint))
+	defer cancel()
+
+	var lastID uint64
+	if lastEventID := c.GetHeader("Last-Event-ID"); lastEventID != "" {
+		lastID, _ = strconv.ParseUint(lastEventID, 10, 64)
+	}
+	var missed []models.Notification
+	replaying := lastID > 0
+	if replaying {
+		var err error
+		if missed, err = missedNotifications(userID.(uint), lastID); err != nil {
+			c.JSON(http.StatusInternalServerError, models.Response{
+				Code:    http.StatusInternalServerError,
+				Message: "获取通知失败: " + err.Error(),
+			})
+			return
+		}
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Writer.WriteHeaderNow()
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		// 分批补发，直到没有更多断线期间的通知
+		if replaying {
+			for _, n := range missed {
+				writeNotificationEvent(c, n)
+				lastID = uint64(n.ID)
+			}
+			if len(missed) < streamReplayBatch {
+				replaying = false
+				missed = nil
+				return true
+			}
+			var err error
+			if missed, err = missedNotifications(userID.(uint), lastID); err != nil {
+				// 结束连接，客户端重连时从最后一条已发送的通知继续补发
+				log.Printf("补发通知失败: %v", err)
+				return false
+			}
+			return true
+		}
+
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case n := <-ch:
+			// 补发过的通知不再重复推送
+			if uint64(n.ID) > lastID {
+				writeNotificationEvent(c, n)
+			}
+			return true
+		case <-heartbeat.C:
+			io.WriteString(w, ": ping\n\n")
+			return true
+		}
+	})
+}
+
+// missedNotifications 查询ID大于afterID的一批通知，用于断线重连后补发
+func missedNotifications(userID uint, afterID uint64) ([]models.Notification, error) {
+	var missed []models.Notification
+	err := config.DB.Preload("Actor").Where("recipient_id = ? AND id > ?", userID, afterID).
+		Order("id asc").Limit(streamReplayBatch).Find(&missed).Error
+	return missed, err
+}
+
+// writeNotificationEvent 写入一条通知事件
+func writeNotificationEvent(c *gin.Context, n models.Notification) {
+	c.Render(-1, sse.Event{Id: strconv.FormatUint(uint64(n.ID), 10), Event: "notification", Data: n})
+}
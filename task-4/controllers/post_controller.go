@@ -1,15 +1,29 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
+	"net/url"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/xhy/blog-api/cache"
 	"github.com/xhy/blog-api/config"
+	"github.com/xhy/blog-api/filter"
 	"github.com/xhy/blog-api/models"
+	"github.com/xhy/blog-api/utils"
+	"github.com/xhy/blog-api/views"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// postVersionHeader 返回文章强ETag的响应头，更新文章时作为If-Match的值
+const postVersionHeader = "X-Post-Version"
+
+// errPreconditionFailed If-Match与文章当前的版本不一致
+var errPreconditionFailed = errors.New("文章已被修改，请重新获取后再更新")
+
 // CreatePost 创建文章
 func CreatePost(c *gin.Context) {
 	var input models.PostInput
@@ -33,14 +47,41 @@ func CreatePost(c *gin.Context) {
 		return
 	}
 
+	if !validSlugInput(c, input.Slug) {
+		return
+	}
+
+	// 检查垃圾内容
+	if _, ok := filterContent(c, &filter.Content{Kind: filter.KindPost, UserID: userID.(uint), Text: input.Title + "\n" + input.Content}); !ok {
+		return
+	}
+
 	// 创建文章
 	post := models.Post{
-		Title:   input.Title,
-		Content: input.Content,
-		UserID:  userID.(uint),
+		Title:      input.Title,
+		Content:    input.Content,
+		Format:     input.Format,
+		Moderation: moderationPolicyInput(input.Moderation),
+		UserID:     userID.(uint),
 	}
 
-	if err := config.DB.Create(&post).Error; err != nil {
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&post).Error; err != nil {
+			return err
+		}
+		if err := post.SetSlug(tx, input.Slug, input.Slug != ""); err != nil {
+			return err
+		}
+		return recordAudit(c, tx, models.AuditCreate, models.TargetPost, post.ID, nil, &post)
+	})
+	if err != nil {
+		if errors.Is(err, models.ErrSlugTaken) {
+			c.JSON(http.StatusConflict, models.Response{
+				Code:    http.StatusConflict,
+				Message: err.Error(),
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, models.Response{
 			Code:    http.StatusInternalServerError,
 			Message: "文章创建失败: " + err.Error(),
@@ -48,6 +89,9 @@ func CreatePost(c *gin.Context) {
 		return
 	}
 
+	sitemapPut(&post)
+	invalidatePostList(c)
+
 	c.JSON(http.StatusCreated, models.Response{
 		Code:    http.StatusCreated,
 		Message: "文章创建成功",
@@ -60,44 +104,157 @@ func GetPosts(c *gin.Context) {
 	var posts []models.Post
 
 	// 分页参数
+	pageSize, offset := parsePagination(c)
+
+	// 查询文章列表
+	err := cache.GetOrLoad(c.Request.Context(), config.Cache, postListCacheKey(c, pageSize, offset), cacheTTL(), &posts, func() (interface{}, error) {
+		var posts []models.Post
+		err := config.DB.Scopes(models.VisiblePosts).Preload("User").Order("created_at desc").Limit(pageSize).Offset(offset).Find(&posts).Error
+		return posts, err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "获取文章列表失败: " + err.Error(),
+		})
+		return
+	}
+
+	// 填充表态统计，表态是响应的一部分，需要计入ETag
+	if err := fillPostReactions(c, posts); err != nil {
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "获取表态统计失败: " + err.Error(),
+		})
+		return
+	}
+
+	// 列表只返回Markdown源文，阅读量读取最新值
+	ids := make([]uint, len(posts))
+	for i := range posts {
+		ids[i] = posts[i].ID
+	}
+	counts, err := currentViewCounts(ids)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "获取阅读量失败: " + err.Error(),
+		})
+		return
+	}
+	for i := range posts {
+		posts[i].HideRendered()
+		posts[i].ViewCount = counts[posts[i].ID]
+	}
+
+	// 列表和表态未变化时返回304，表态中包含当前用户是否已表态，响应随认证信息变化
+	// 取消表态不留下修改时间，只按ETag判断，不返回Last-Modified
+	c.Header("Vary", "Authorization")
+	if checkNotModified(c, postListETag(posts), time.Time{}) {
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Code:    http.StatusOK,
+		Message: "获取文章列表成功",
+		Data:    posts,
+	})
+}
+
+// parsePagination 解析page和pageSize分页参数，返回每页数量和偏移量
+func parsePagination(c *gin.Context) (int, int) {
 	pageStr := c.DefaultQuery("page", "1")
 	pageSizeStr := c.DefaultQuery("pageSize", "10")
-	
+
 	page, err := strconv.Atoi(pageStr)
 	if err != nil || page < 1 {
 		page = 1
 	}
-	
+
 	pageSize, err := strconv.Atoi(pageSizeStr)
 	if err != nil || pageSize < 1 || pageSize > 100 {
 		pageSize = 10
 	}
-	
-	offset := (page - 1) * pageSize
 
-	// 查询文章列表
-	if err := config.DB.Preload("User").Order("created_at desc").Limit(pageSize).Offset(offset).Find(&posts).Error; err != nil {
+	return pageSize, (page - 1) * pageSize
+}
+
+// parseID 把路径或查询参数中的ID解析为正整数，失败时写入400响应
+// 查询前必须先解析，直接把字符串传给First等方法时GORM会把它当作SQL条件
+func parseID(c *gin.Context, name, value string) (uint, bool) {
+	id, err := strconv.ParseUint(value, 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, models.Response{
+			Code:    http.StatusBadRequest,
+			Message: name + "必须是正整数",
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// GetPost 获取单个文章
+// 默认只返回Markdown源文，render=html时同时返回过滤后的HTML和目录
+func GetPost(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.Response{
+			Code:    http.StatusNotFound,
+			Message: "文章不存在",
+		})
+		return
+	}
+	showPost(c, uint(id))
+}
+
+// GetPostBySlug 根据别名获取文章，使用旧别名访问时重定向到当前别名
+func GetPostBySlug(c *gin.Context) {
+	slug := c.Param("slug")
+	var postSlug models.PostSlug
+	var post models.Post
+
+	err := config.DB.Where("slug = ?", slug).First(&postSlug).Error
+	if err == nil {
+		err = config.DB.Select("id", "slug").First(&post, postSlug.PostID).Error
+	}
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, models.Response{
+				Code:    http.StatusNotFound,
+				Message: "文章不存在",
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, models.Response{
 			Code:    http.StatusInternalServerError,
-			Message: "获取文章列表失败: " + err.Error(),
+			Message: "获取文章失败: " + err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, models.Response{
-		Code:    http.StatusOK,
-		Message: "获取文章列表成功",
-		Data:    posts,
-	})
+	if post.Slug != slug {
+		location := "/api/posts/by-slug/" + url.PathEscape(post.Slug)
+		if c.Request.URL.RawQuery != "" {
+			location += "?" + c.Request.URL.RawQuery
+		}
+		c.Redirect(http.StatusMovedPermanently, location)
+		return
+	}
+
+	showPost(c, post.ID)
 }
 
-// GetPost 获取单个文章
-func GetPost(c *gin.Context) {
-	id := c.Param("id")
+// showPost 查询文章及评论并输出，文章、作者和评论从缓存读取
+func showPost(c *gin.Context, id uint) {
 	var post models.Post
 
 	// 查询文章
-	if err := config.DB.Preload("User").Preload("Comments").Preload("Comments.User").First(&post, id).Error; err != nil {
+	err := cache.GetOrLoad(c.Request.Context(), config.Cache, postCacheKey(id), cacheTTL(), &post, func() (interface{}, error) {
+		var post models.Post
+		err := config.DB.Preload("User").Preload("Comments", "status = ?", models.CommentApproved).Preload("Comments.User").First(&post, id).Error
+		return post, err
+	})
+	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, models.Response{
 				Code:    http.StatusNotFound,
@@ -112,6 +269,59 @@ func GetPost(c *gin.Context) {
 		return
 	}
 
+	// 被举报隐藏的文章只有作者可见
+	if !canViewPost(c, &post) {
+		c.JSON(http.StatusNotFound, models.Response{
+			Code:    http.StatusNotFound,
+			Message: "文章不存在",
+		})
+		return
+	}
+
+	if err := countView(c, &post); err != nil {
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "获取阅读量失败: " + err.Error(),
+		})
+		return
+	}
+
+	// 填充文章和评论的表态统计，表态是响应的一部分，需要计入ETag
+	summaries, err := reactionSummaries(models.TargetPost, []uint{post.ID}, optionalUserID(c))
+	if err == nil {
+		post.Reactions = summaries[post.ID]
+		err = fillCommentReactions(c, post.Comments)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "获取表态统计失败: " + err.Error(),
+		})
+		return
+	}
+
+	// 文章、评论和表态未变化时返回304，表态中包含当前用户是否已表态，响应随认证信息变化
+	// 取消表态不留下修改时间，只按ETag判断，不返回Last-Modified
+	c.Header(postVersionHeader, postVersionETag(&post))
+	c.Header("Vary", "Authorization")
+	if checkNotModified(c, postETag(&post), time.Time{}) {
+		return
+	}
+
+	if c.Query("render") == "html" {
+		// 兼容渲染功能上线前保存的文章
+		if post.ContentHTML == "" {
+			post.Render()
+		}
+		for i := range post.Comments {
+			if post.Comments[i].ContentHTML == "" {
+				post.Comments[i].Render()
+			}
+		}
+	} else {
+		post.HideRendered()
+	}
+
 	c.JSON(http.StatusOK, models.Response{
 		Code:    http.StatusOK,
 		Message: "获取文章成功",
@@ -121,7 +331,10 @@ func GetPost(c *gin.Context) {
 
 // UpdatePost 更新文章
 func UpdatePost(c *gin.Context) {
-	id := c.Param("id")
+	id, ok := parseID(c, "id", c.Param("id"))
+	if !ok {
+		return
+	}
 	var input models.PostInput
 	var post models.Post
 
@@ -169,11 +382,71 @@ func UpdatePost(c *gin.Context) {
 		return
 	}
 
+	if !validSlugInput(c, input.Slug) {
+		return
+	}
+
+	// 检查垃圾内容
+	if _, ok := filterContent(c, &filter.Content{Kind: filter.KindPost, ID: post.ID, UserID: post.UserID, Text: input.Title + "\n" + input.Content}); !ok {
+		return
+	}
+
 	// 更新文章
+	before := post
+	titleChanged := post.Title != input.Title
 	post.Title = input.Title
 	post.Content = input.Content
+	if input.Format != "" {
+		post.Format = input.Format
+	}
+	if input.Moderation != "" {
+		post.Moderation = moderationPolicyInput(input.Moderation)
+	}
 
-	if err := config.DB.Save(&post).Error; err != nil {
+	// 指定了新别名时使用指定的别名，否则自动生成的别名随标题变化，旧别名保留用于重定向
+	ifMatch := c.GetHeader("If-Match")
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		// 携带If-Match时锁定文章，确认客户端获取文章之后没有被其他请求修改，防止覆盖他人的更新
+		if ifMatch != "" {
+			var current models.Post
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&current, post.ID).Error; err != nil {
+				return err
+			}
+			if !etagMatchStrong(ifMatch, postVersionETag(&current)) {
+				return errPreconditionFailed
+			}
+		}
+
+		// 计数列和隐藏状态由各自的流程更新，保存文章时不写回读取时的旧值
+		if err := tx.Omit("comment_count", "view_count", "hidden").Save(&post).Error; err != nil {
+			return err
+		}
+		if input.Slug != "" && input.Slug != post.Slug {
+			if err := post.SetSlug(tx, input.Slug, true); err != nil {
+				return err
+			}
+		} else if titleChanged && !post.SlugCustom {
+			if err := post.SetSlug(tx, "", false); err != nil {
+				return err
+			}
+		}
+		return recordAudit(c, tx, models.AuditUpdate, models.TargetPost, post.ID, &before, &post)
+	})
+	if err != nil {
+		if errors.Is(err, models.ErrSlugTaken) {
+			c.JSON(http.StatusConflict, models.Response{
+				Code:    http.StatusConflict,
+				Message: err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, errPreconditionFailed) {
+			c.JSON(http.StatusPreconditionFailed, models.Response{
+				Code:    http.StatusPreconditionFailed,
+				Message: err.Error(),
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, models.Response{
 			Code:    http.StatusInternalServerError,
 			Message: "更新文章失败: " + err.Error(),
@@ -181,6 +454,14 @@ func UpdatePost(c *gin.Context) {
 		return
 	}
 
+	sitemapPut(&post)
+	invalidatePost(c, post.ID)
+
+	// 重新读取保存后的文章，返回新的版本，用于下一次更新的If-Match
+	if err := config.DB.First(&post, post.ID).Error; err == nil {
+		c.Header(postVersionHeader, postVersionETag(&post))
+	}
+
 	c.JSON(http.StatusOK, models.Response{
 		Code:    http.StatusOK,
 		Message: "更新文章成功",
@@ -190,7 +471,10 @@ func UpdatePost(c *gin.Context) {
 
 // DeletePost 删除文章
 func DeletePost(c *gin.Context) {
-	id := c.Param("id")
+	id, ok := parseID(c, "id", c.Param("id"))
+	if !ok {
+		return
+	}
 	var post models.Post
 
 	// 获取当前用户ID
@@ -228,8 +512,23 @@ func DeletePost(c *gin.Context) {
 		return
 	}
 
-	// 删除文章
-	if err := config.DB.Delete(&post).Error; err != nil {
+	// 文章连同评论和附件一起移入回收站，锁定文章防止并发发表的评论遗留在已删除的文章下
+	if err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&post, post.ID).Error; err != nil {
+			return err
+		}
+		if err := recordAudit(c, tx, models.AuditDelete, models.TargetPost, post.ID, &post, nil); err != nil {
+			return err
+		}
+		return models.TrashPost(tx, &post)
+	}); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, models.Response{
+				Code:    http.StatusNotFound,
+				Message: "文章不存在",
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, models.Response{
 			Code:    http.StatusInternalServerError,
 			Message: "删除文章失败: " + err.Error(),
@@ -237,8 +536,74 @@ func DeletePost(c *gin.Context) {
 		return
 	}
 
+	sitemapRemove(post.ID)
+	invalidatePost(c, post.ID)
+
 	c.JSON(http.StatusOK, models.Response{
 		Code:    http.StatusOK,
 		Message: "删除文章成功",
 	})
-} 
\ No newline at end of file
+}
+
+// moderationPolicyInput 将输入的评论审核策略转换为存储的值，default表示使用全站策略
+func moderationPolicyInput(policy string) string {
+	if policy == "default" {
+		return ""
+	}
+	return policy
+}
+
+// validSlugInput 检查作者指定的别名格式，不合法时写入400响应
+func validSlugInput(c *gin.Context, slug string) bool {
+	if slug == "" || utils.IsValidSlug(slug) {
+		return true
+	}
+	c.JSON(http.StatusBadRequest, models.Response{
+		Code:    http.StatusBadRequest,
+		Message: "别名只能包含小写字母、数字和连字符",
+	})
+	return false
+}
+
+// canViewPost 判断当前用户能否查看文章，被举报隐藏的文章只有作者可见
+func canViewPost(c *gin.Context, post *models.Post) bool {
+	return !post.Hidden || optionalUserID(c) == post.UserID
+}
+
+// countView 记录一次阅读并在响应中显示最新的阅读量
+// 爬虫不计入，登录用户按用户ID去重，匿名访客按IP去重
+func countView(c *gin.Context, post *models.Post) error {
+	if !views.IsBot(c.Request.UserAgent()) {
+		viewer := "ip:" + c.ClientIP()
+		if userID := optionalUserID(c); userID != 0 {
+			viewer = "user:" + strconv.FormatUint(uint64(userID), 10)
+		}
+		views.Default.Record(post.ID, viewer)
+	}
+	counts, err := currentViewCounts([]uint{post.ID})
+	if err != nil {
+		return err
+	}
+	post.ViewCount = counts[post.ID]
+	return nil
+}
+
+// currentViewCounts 返回文章当前的阅读量，即数据库中的值加上尚未写入的部分
+// 缓存中的文章保留加载时的阅读量，计数器写入数据库后就会过时，因此显示时重新读取
+func currentViewCounts(ids []uint) (map[uint]int64, error) {
+	counts := make(map[uint]int64, len(ids))
+	if len(ids) == 0 {
+		return counts, nil
+	}
+	var posts []models.Post
+	if err := config.DB.Select("id", "view_count").Where("id IN ?", ids).Find(&posts).Error; err != nil {
+		return nil, err
+	}
+	for _, p := range posts {
+		counts[p.ID] = p.ViewCount
+	}
+	for _, id := range ids {
+		counts[id] += views.Default.Pending(id)
+	}
+	return counts, nil
+}
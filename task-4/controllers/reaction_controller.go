@@ -0,0 +1,314 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xhy/blog-api/config"
+	"github.com/xhy/blog-api/models"
+	"github.com/xhy/blog-api/notify"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AddPostReaction 对文章表态
+func AddPostReaction(c *gin.Context) {
+	addReaction(c, models.TargetPost)
+}
+
+// RemovePostReaction 取消对文章的表态
+func RemovePostReaction(c *gin.Context) {
+	removeReaction(c, models.TargetPost)
+}
+
+// AddCommentReaction 对评论表态
+func AddCommentReaction(c *gin.Context) {
+	addReaction(c, models.TargetComment)
+}
+
+// RemoveCommentReaction 取消对评论的表态
+func RemoveCommentReaction(c *gin.Context) {
+	removeReaction(c, models.TargetComment)
+}
+
+// addReaction 添加表态，重复表态不会报错
+func addReaction(c *gin.Context, targetType string) {
+	var input models.ReactionInput
+
+	// 获取当前用户ID
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.Response{
+			Code:    http.StatusUnauthorized,
+			Message: "未授权",
+		})
+		return
+	}
+
+	target, ok := findReactionTarget(c, targetType)
+	if !ok {
+		return
+	}
+	targetID := target.ID
+
+	// 绑定请求数据
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+	if !models.IsReactionEmoji(input.Emoji) {
+		c.JSON(http.StatusBadRequest, models.Response{
+			Code:    http.StatusBadRequest,
+			Message: "不支持的表态: " + input.Emoji,
+		})
+		return
+	}
+
+	// 依赖唯一索引去重，并发表态时不会重复计数
+	reaction := models.Reaction{
+		TargetType: targetType,
+		TargetID:   targetID,
+		UserID:     userID.(uint),
+		Emoji:      input.Emoji,
+	}
+	result := config.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&reaction)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "表态失败: " + result.Error.Error(),
+		})
+		return
+	}
+
+	summaries, err := reactionSummaries(targetType, []uint{targetID}, userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "获取表态统计失败: " + err.Error(),
+		})
+		return
+	}
+
+	// 新表态时通知作者
+	status := http.StatusOK
+	if result.RowsAffected > 0 {
+		status = http.StatusCreated
+		notification := models.Notification{
+			RecipientID: target.OwnerID,
+			ActorID:     reaction.UserID,
+			Type:        models.NotificationReaction,
+			PostID:      target.PostID,
+			Emoji:       reaction.Emoji,
+		}
+		if targetType == models.TargetComment {
+			notification.CommentID = &target.ID
+		}
+		notify.Send(notification)
+	}
+	c.JSON(status, models.Response{
+		Code:    status,
+		Message: "表态成功",
+		Data:    summaries[targetID],
+	})
+}
+
+// removeReaction 取消表态，未表态时同样返回成功
+func removeReaction(c *gin.Context, targetType string) {
+	emoji := c.Param("emoji")
+
+	// 获取当前用户ID
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.Response{
+			Code:    http.StatusUnauthorized,
+			Message: "未授权",
+		})
+		return
+	}
+
+	target, ok := findReactionTarget(c, targetType)
+	if !ok {
+		return
+	}
+	targetID := target.ID
+
+	if err := config.DB.Where("target_type = ? AND target_id = ? AND user_id = ? AND emoji = ?", targetType, targetID, userID, emoji).
+		Delete(&models.Reaction{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "取消表态失败: " + err.Error(),
+		})
+		return
+	}
+
+	summaries, err := reactionSummaries(targetType, []uint{targetID}, userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "获取表态统计失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Code:    http.StatusOK,
+		Message: "取消表态成功",
+		Data:    summaries[targetID],
+	})
+}
+
+// reactionTarget 表态对象
+type reactionTarget struct {
+	ID      uint
+	OwnerID uint // 文章或评论的作者
+	PostID  uint
+}
+
+// findReactionTarget 确认表态对象存在，评论还要求已经发布且所属文章存在
+func findReactionTarget(c *gin.Context, targetType string) (reactionTarget, bool) {
+	var target reactionTarget
+	id, ok := parseID(c, "id", c.Param("id"))
+	if !ok {
+		return target, false
+	}
+	var err error
+
+	if targetType == models.TargetPost {
+		var post models.Post
+		err = config.DB.Scopes(models.VisiblePosts).First(&post, id).Error
+		target = reactionTarget{ID: post.ID, OwnerID: post.UserID, PostID: post.ID}
+	} else {
+		var comment models.Comment
+		err = config.DB.Where("status = ?", models.CommentApproved).First(&comment, id).Error
+		if err == nil {
+			err = config.DB.Scopes(models.VisiblePosts).Select("id").First(&models.Post{}, comment.PostID).Error
+		}
+		target = reactionTarget{ID: comment.ID, OwnerID: comment.UserID, PostID: comment.PostID}
+	}
+
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			message := "文章不存在"
+			if targetType == models.TargetComment {
+				message = "评论不存在"
+			}
+			c.JSON(http.StatusNotFound, models.Response{
+				Code:    http.StatusNotFound,
+				Message: message,
+			})
+			return target, false
+		}
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "获取表态对象失败: " + err.Error(),
+		})
+		return target, false
+	}
+	return target, true
+}
+
+// reactionSummaries 批量统计多个对象的表态，userID不为0时标记该用户已表态的表情
+// 计数直接从表态记录聚合，不维护冗余计数，并发表态时结果始终准确
+func reactionSummaries(targetType string, ids []uint, userID uint) (map[uint][]models.ReactionSummary, error) {
+	result := make(map[uint][]models.ReactionSummary, len(ids))
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	var rows []struct {
+		TargetID uint
+		Emoji    string
+		Count    int64
+	}
+	if err := config.DB.Model(&models.Reaction{}).
+		Select("target_id, emoji, COUNT(*) AS count").
+		Where("target_type = ? AND target_id IN ?", targetType, ids).
+		Group("target_id, emoji").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	mine := make(map[uint]map[string]bool)
+	if userID != 0 {
+		var own []models.Reaction
+		if err := config.DB.Select("target_id, emoji").
+			Where("target_type = ? AND target_id IN ? AND user_id = ?", targetType, ids, userID).
+			Find(&own).Error; err != nil {
+			return nil, err
+		}
+		for _, r := range own {
+			if mine[r.TargetID] == nil {
+				mine[r.TargetID] = make(map[string]bool)
+			}
+			mine[r.TargetID][r.Emoji] = true
+		}
+	}
+
+	counts := make(map[uint]map[string]int64)
+	for _, row := range rows {
+		if counts[row.TargetID] == nil {
+			counts[row.TargetID] = make(map[string]int64)
+		}
+		counts[row.TargetID][row.Emoji] = row.Count
+	}
+
+	// 按固定顺序输出，只包含有人表态的表情
+	for _, id := range ids {
+		summaries := []models.ReactionSummary{}
+		for _, e := range models.ReactionEmojis {
+			if count := counts[id][e.Name]; count > 0 {
+				summaries = append(summaries, models.ReactionSummary{
+					Emoji:   e.Name,
+					Symbol:  e.Symbol,
+					Count:   count,
+					Reacted: mine[id][e.Name],
+				})
+			}
+		}
+		result[id] = summaries
+	}
+	return result, nil
+}
+
+// fillPostReactions 为文章列表填充表态统计
+func fillPostReactions(c *gin.Context, posts []models.Post) error {
+	ids := make([]uint, len(posts))
+	for i := range posts {
+		ids[i] = posts[i].ID
+	}
+	summaries, err := reactionSummaries(models.TargetPost, ids, optionalUserID(c))
+	if err != nil {
+		return err
+	}
+	for i := range posts {
+		posts[i].Reactions = summaries[posts[i].ID]
+	}
+	return nil
+}
+
+// fillCommentReactions 为评论列表填充表态统计
+func fillCommentReactions(c *gin.Context, comments []models.Comment) error {
+	ids := make([]uint, len(comments))
+	for i := range comments {
+		ids[i] = comments[i].ID
+	}
+	summaries, err := reactionSummaries(models.TargetComment, ids, optionalUserID(c))
+	if err != nil {
+		return err
+	}
+	for i := range comments {
+		comments[i].Reactions = summaries[comments[i].ID]
+	}
+	return nil
+}
+
+// optionalUserID 返回当前登录用户ID，未登录时返回0
+func optionalUserID(c *gin.Context) uint {
+	if userID, exists := c.Get("userID"); exists {
+		return userID.(uint)
+	}
+	return 0
+}
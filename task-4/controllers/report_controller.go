@@ -0,0 +1,350 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xhy/blog-api/config"
+	"github.com/xhy/blog-api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// errReportExists 用户对同一内容已有未处理的举报
+var errReportExists = errors.New("你已经举报过该内容，请等待处理")
+
+// ReportPost 举报文章
+func ReportPost(c *gin.Context) {
+	createReport(c, models.TargetPost)
+}
+
+// ReportComment 举报评论
+func ReportComment(c *gin.Context) {
+	createReport(c, models.TargetComment)
+}
+
+// createReport 创建举报，未处理的举报达到阈值时自动隐藏内容
+func createReport(c *gin.Context, targetType string) {
+	var input models.ReportInput
+
+	// 获取当前用户ID
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.Response{
+			Code:    http.StatusUnauthorized,
+			Message: "未授权",
+		})
+		return
+	}
+
+	// 绑定请求数据
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+	if !models.IsReportReason(input.Reason) {
+		c.JSON(http.StatusBadRequest, models.Response{
+			Code:    http.StatusBadRequest,
+			Message: "不支持的举报原因: " + input.Reason,
+		})
+		return
+	}
+
+	// 举报对象必须对其他用户可见
+	target, ok := findReactionTarget(c, targetType)
+	if !ok {
+		return
+	}
+	if target.OwnerID == userID.(uint) {
+		c.JSON(http.StatusBadRequest, models.Response{
+			Code:    http.StatusBadRequest,
+			Message: "不能举报自己的内容",
+		})
+		return
+	}
+
+	report := models.Report{
+		ReporterID: userID.(uint),
+		TargetType: targetType,
+		TargetID:   target.ID,
+		Reason:     input.Reason,
+		Detail:     input.Detail,
+	}
+
+	var hidden bool
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&report)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errReportExists
+		}
+
+		threshold := config.GetConfig().Reports.HideThreshold
+		if threshold <= 0 {
+			return nil
+		}
+		var open int64
+		if err := tx.Model(&models.Report{}).
+			Where("target_type = ? AND target_id = ? AND status = ?", targetType, target.ID, models.ReportOpen).
+			Count(&open).Error; err != nil {
+			return err
+		}
+		if open < int64(threshold) {
+			return nil
+		}
+
+		var err error
+		hidden, err = hideReportedTarget(c, tx, targetType, target.ID, models.CommentPending, 0)
+		return err
+	})
+	if err != nil {
+		if err == errReportExists {
+			c.JSON(http.StatusConflict, models.Response{
+				Code:    http.StatusConflict,
+				Message: err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "举报失败: " + err.Error(),
+		})
+		return
+	}
+
+	if hidden {
+		reportedTargetChanged(c, targetType, target)
+	}
+
+	c.JSON(http.StatusCreated, models.Response{
+		Code:    http.StatusCreated,
+		Message: "举报成功",
+		Data:    report,
+	})
+}
+
+// GetReports 获取举报队列，默认只返回未处理的举报，最早提交的在前（仅版主）
+func GetReports(c *gin.Context) {
+	var reports []models.Report
+
+	if _, ok := requireModerator(c); !ok {
+		return
+	}
+
+	// 分页参数
+	pageSize, offset := parsePagination(c)
+
+	query := config.DB.Where("status = ?", c.DefaultQuery("status", models.ReportOpen))
+	if targetType := c.Query("target_type"); targetType != "" {
+		query = query.Where("target_type = ?", targetType)
+	}
+	if targetID := c.Query("target_id"); targetID != "" {
+		query = query.Where("target_id = ?", targetID)
+	}
+
+	if err := query.Preload("Reporter").Preload("Decider").
+		Order("created_at asc").Limit(pageSize).Offset(offset).
+		Find(&reports).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "获取举报列表失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Code:    http.StatusOK,
+		Message: "获取举报列表成功",
+		Data:    reports,
+	})
+}
+
+// ResolveReport 举报成立：隐藏内容，同一内容的所有未处理举报一并处理（仅版主）
+func ResolveReport(c *gin.Context) {
+	decideReport(c, models.ReportResolved)
+}
+
+// DismissReport 举报不成立：恢复被自动隐藏的内容，同一内容的所有未处理举报一并处理（仅版主）
+func DismissReport(c *gin.Context) {
+	decideReport(c, models.ReportDismissed)
+}
+
+// decideReport 处理举报，记录处理的版主、时间和说明
+func decideReport(c *gin.Context, status string) {
+	var input models.ReportDecisionInput
+	var report models.Report
+
+	user, ok := requireModerator(c)
+	if !ok {
+		return
+	}
+
+	// 绑定请求数据，处理说明可以省略
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, models.Response{
+				Code:    http.StatusBadRequest,
+				Message: "请求参数错误: " + err.Error(),
+			})
+			return
+		}
+	}
+
+	id, ok := parseID(c, "id", c.Param("id"))
+	if !ok {
+		return
+	}
+	if err := config.DB.First(&report, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, models.Response{
+				Code:    http.StatusNotFound,
+				Message: "举报不存在",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "获取举报失败: " + err.Error(),
+		})
+		return
+	}
+
+	var closed int64
+	var changed bool
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		closed, err = models.CloseReports(tx, report.TargetType, report.TargetID, status, user.ID, input.Note)
+		if err != nil || closed == 0 {
+			return err
+		}
+		if status == models.ReportResolved {
+			changed, err = hideReportedTarget(c, tx, report.TargetType, report.TargetID, models.CommentRejected, user.ID)
+		} else {
+			changed, err = restoreReportedTarget(c, tx, report.TargetType, report.TargetID, user.ID)
+		}
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "处理举报失败: " + err.Error(),
+		})
+		return
+	}
+	if closed == 0 {
+		c.JSON(http.StatusConflict, models.Response{
+			Code:    http.StatusConflict,
+			Message: "举报已处理",
+		})
+		return
+	}
+
+	if changed {
+		target := reactionTarget{ID: report.TargetID}
+		if report.TargetType == models.TargetComment {
+			var comment models.Comment
+			config.DB.Unscoped().Select("id", "post_id").First(&comment, report.TargetID)
+			target.PostID = comment.PostID
+		} else {
+			target.PostID = report.TargetID
+		}
+		reportedTargetChanged(c, report.TargetType, target)
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Code:    http.StatusOK,
+		Message: "处理举报成功",
+		Data:    gin.H{"closed": closed},
+	})
+}
+
+// hideReportedTarget 隐藏被举报的内容并写入审计日志，返回内容是否发生变化
+// 文章标记为隐藏；已发布的评论改为commentStatus：自动隐藏时回到审核队列，举报成立时拒绝
+func hideReportedTarget(c *gin.Context, tx *gorm.DB, targetType string, id uint, commentStatus string, moderatorID uint) (bool, error) {
+	if targetType == models.TargetPost {
+		return setPostHidden(c, tx, id, true, moderatorID)
+	}
+
+	var comment models.Comment
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&comment, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	switch {
+	case comment.Status == models.CommentApproved:
+	case comment.Status == models.CommentPending && commentStatus == models.CommentRejected:
+		// 举报成立时，已经被自动隐藏的评论同样改为拒绝
+	default:
+		return false, nil
+	}
+	return moderateAudited(c, tx, &comment, commentStatus, moderatorID)
+}
+
+// restoreReportedTarget 举报不成立时恢复被隐藏的内容并写入审计日志，返回内容是否发生变化
+func restoreReportedTarget(c *gin.Context, tx *gorm.DB, targetType string, id uint, moderatorID uint) (bool, error) {
+	if targetType == models.TargetPost {
+		return setPostHidden(c, tx, id, false, moderatorID)
+	}
+
+	var comment models.Comment
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&comment, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	if !comment.ReportHidden() {
+		return false, nil
+	}
+	return moderateAudited(c, tx, &comment, models.CommentApproved, moderatorID)
+}
+
+// setPostHidden 修改文章的隐藏状态并写入审计日志，返回状态是否发生变化
+// moderatorID为0表示举报达到阈值自动隐藏，以系统身份记录
+func setPostHidden(c *gin.Context, tx *gorm.DB, id uint, hidden bool, moderatorID uint) (bool, error) {
+	result := tx.Model(&models.Post{}).Where("id = ? AND hidden = ?", id, !hidden).UpdateColumn("hidden", hidden)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false, result.Error
+	}
+	if moderatorID == 0 {
+		return true, recordSystemAudit(c, tx, models.AuditUpdate, models.TargetPost, id, gin.H{"hidden": !hidden}, gin.H{"hidden": hidden})
+	}
+	return true, recordAudit(c, tx, models.AuditUpdate, models.TargetPost, id, gin.H{"hidden": !hidden}, gin.H{"hidden": hidden})
+}
+
+// reportedTargetChanged 内容被隐藏或恢复后刷新缓存和站点地图
+func reportedTargetChanged(c *gin.Context, targetType string, target reactionTarget) {
+	invalidatePost(c, target.PostID)
+	if targetType != models.TargetPost {
+		return
+	}
+
+	var post models.Post
+	if err := config.DB.Select("id", "slug", "updated_at", "hidden").First(&post, target.ID).Error; err == nil {
+		sitemapPut(&post)
+	}
+}
+
+// requireModerator 要求当前用户为版主或管理员，否则写入403响应
+func requireModerator(c *gin.Context) (*models.User, bool) {
+	user, ok := currentUser(c)
+	if !ok {
+		return nil, false
+	}
+	if !user.IsModerator() {
+		c.JSON(http.StatusForbidden, models.Response{
+			Code:    http.StatusForbidden,
+			Message: "需要版主权限",
+		})
+		return nil, false
+	}
+	return user, true
+}
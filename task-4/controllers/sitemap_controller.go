@@ -0,0 +1,132 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xhy/blog-api/config"
+	"github.com/xhy/blog-api/models"
+	"github.com/xhy/blog-api/sitemap"
+)
+
+// 首次请求时加载站点地图，避免并发请求重复查询
+var sitemapLoadMu sync.Mutex
+
+// GetSitemap 站点地图，URL超过单个文件上限时返回站点地图索引
+func GetSitemap(c *gin.Context) {
+	if !ensureSitemap(c) {
+		return
+	}
+
+	if sitemap.Default.PageCount() == 1 {
+		writeSitemap(c, sitemap.Default.Page(0))
+		return
+	}
+	writeSitemap(c, sitemap.Default.Index(sitemapPageURL))
+}
+
+// GetSitemapPage 站点地图索引中的分页文件，文件名为从1开始的页码
+func GetSitemapPage(c *gin.Context) {
+	if !ensureSitemap(c) {
+		return
+	}
+
+	page, err := strconv.Atoi(strings.TrimSuffix(c.Param("file"), ".xml"))
+	var doc *sitemap.Document
+	if err == nil {
+		doc = sitemap.Default.Page(page - 1)
+	}
+	if doc == nil {
+		c.JSON(http.StatusNotFound, models.Response{
+			Code:    http.StatusNotFound,
+			Message: "站点地图不存在",
+		})
+		return
+	}
+	writeSitemap(c, doc)
+}
+
+// GetRobots 根据配置生成robots.txt，并声明站点地图地址
+func GetRobots(c *gin.Context) {
+	site := config.GetConfig().Site
+
+	var buf strings.Builder
+	buf.WriteString("User-agent: *\n")
+	for _, path := range site.RobotsDisallow {
+		buf.WriteString("Disallow: " + path + "\n")
+	}
+	if len(site.RobotsDisallow) == 0 {
+		buf.WriteString("Disallow:\n")
+	}
+	buf.WriteString("\nSitemap: " + site.URL + "/sitemap.xml\n")
+
+	c.Header("Cache-Control", "public, max-age=86400")
+	c.String(http.StatusOK, buf.String())
+}
+
+// ensureSitemap 首次使用时从数据库加载全部文章，之后由文章的增删改增量更新
+func ensureSitemap(c *gin.Context) bool {
+	if sitemap.Default.Loaded() {
+		return true
+	}
+
+	sitemapLoadMu.Lock()
+	defer sitemapLoadMu.Unlock()
+	if sitemap.Default.Loaded() {
+		return true
+	}
+
+	sitemap.Default.BeginLoad()
+	var posts []models.Post
+	if err := config.DB.Scopes(models.VisiblePosts).Select("id", "slug", "updated_at").Order("id").Find(&posts).Error; err != nil {
+		sitemap.Default.AbortLoad()
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "生成站点地图失败: " + err.Error(),
+		})
+		return false
+	}
+
+	entries := make([]sitemap.Entry, len(posts))
+	for i := range posts {
+		entries[i] = sitemapEntry(&posts[i])
+	}
+	sitemap.Default.Reset(entries)
+	return true
+}
+
+// writeSitemap 输出站点地图并支持条件请求
+func writeSitemap(c *gin.Context, doc *sitemap.Document) {
+	c.Header("Cache-Control", "public, max-age=3600")
+	if checkNotModified(c, doc.ETag, doc.LastMod) {
+		return
+	}
+	c.Data(http.StatusOK, "application/xml; charset=utf-8", doc.Data)
+}
+
+// sitemapPageURL 站点地图分页的对外访问地址，页码从1开始
+func sitemapPageURL(page int) string {
+	return config.GetConfig().Site.URL + "/sitemaps/" + strconv.Itoa(page+1) + ".xml"
+}
+
+// sitemapEntry 文章对应的站点地图条目
+func sitemapEntry(post *models.Post) sitemap.Entry {
+	return sitemap.Entry{ID: post.ID, Loc: postURL(post), LastMod: post.UpdatedAt}
+}
+
+// sitemapPut 文章创建或更新后刷新站点地图，隐藏的文章从站点地图移除
+func sitemapPut(post *models.Post) {
+	if post.Hidden {
+		sitemap.Default.Remove(post.ID)
+		return
+	}
+	sitemap.Default.Put(sitemapEntry(post))
+}
+
+// sitemapRemove 文章删除后从站点地图移除
+func sitemapRemove(id uint) {
+	sitemap.Default.Remove(id)
+}
@@ -0,0 +1,181 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xhy/blog-api/config"
+	"github.com/xhy/blog-api/models"
+	"github.com/xhy/blog-api/utils"
+	"gorm.io/gorm"
+)
+
+// 未指定有效期时的默认有效天数
+const defaultTokenExpiresInDays = 90
+
+// CreateToken 创建个人访问令牌
+func CreateToken(c *gin.Context) {
+	var input models.TokenCreateInput
+
+	// 获取当前用户ID
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.Response{
+			Code:    http.StatusUnauthorized,
+			Message: "未授权",
+		})
+		return
+	}
+
+	// 绑定请求数据
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	// 校验权限范围并去重
+	scopes := make([]string, 0, len(input.Scopes))
+	seen := make(map[string]bool)
+	for _, scope := range input.Scopes {
+		if !models.IsGrantableScope(scope) {
+			c.JSON(http.StatusBadRequest, models.Response{
+				Code:    http.StatusBadRequest,
+				Message: "不支持的权限范围: " + scope,
+			})
+			return
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+
+	days := input.ExpiresInDays
+	if days == 0 {
+		days = defaultTokenExpiresInDays
+	}
+	expiresAt := time.Now().AddDate(0, 0, days)
+
+	// 生成令牌，数据库只保存哈希
+	token, hash, prefix, err := utils.GenerateAccessToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "令牌生成失败",
+		})
+		return
+	}
+
+	pat := models.PersonalAccessToken{
+		UserID:    userID.(uint),
+		Name:      input.Name,
+		TokenHash: hash,
+		Prefix:    prefix,
+		Scopes:    strings.Join(scopes, ","),
+		ExpiresAt: &expiresAt,
+	}
+
+	if err := config.DB.Create(&pat).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "令牌创建失败: " + err.Error(),
+		})
+		return
+	}
+
+	resp := models.NewPersonalAccessTokenResponse(&pat)
+	resp.Token = token
+
+	c.JSON(http.StatusCreated, models.Response{
+		Code:    http.StatusCreated,
+		Message: "令牌创建成功，请妥善保存，令牌只显示一次",
+		Data:    resp,
+	})
+}
+
+// GetTokens 获取当前用户的个人访问令牌列表
+func GetTokens(c *gin.Context) {
+	var tokens []models.PersonalAccessToken
+
+	// 获取当前用户ID
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.Response{
+			Code:    http.StatusUnauthorized,
+			Message: "未授权",
+		})
+		return
+	}
+
+	if err := config.DB.Where("user_id = ?", userID).Order("created_at desc").Find(&tokens).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "获取令牌列表失败: " + err.Error(),
+		})
+		return
+	}
+
+	data := make([]models.PersonalAccessTokenResponse, 0, len(tokens))
+	for i := range tokens {
+		data = append(data, models.NewPersonalAccessTokenResponse(&tokens[i]))
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Code:    http.StatusOK,
+		Message: "获取令牌列表成功",
+		Data:    data,
+	})
+}
+
+// RevokeToken 撤销个人访问令牌
+func RevokeToken(c *gin.Context) {
+	id, ok := parseID(c, "id", c.Param("id"))
+	if !ok {
+		return
+	}
+	var pat models.PersonalAccessToken
+
+	// 获取当前用户ID
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.Response{
+			Code:    http.StatusUnauthorized,
+			Message: "未授权",
+		})
+		return
+	}
+
+	// 只能撤销自己的令牌，他人的令牌按不存在处理
+	if err := config.DB.Where("user_id = ?", userID).First(&pat, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, models.Response{
+				Code:    http.StatusNotFound,
+				Message: "令牌不存在",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "获取令牌失败: " + err.Error(),
+		})
+		return
+	}
+
+	if err := config.DB.Delete(&pat).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "撤销令牌失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Code:    http.StatusOK,
+		Message: "撤销令牌成功",
+	})
+}
@@ -0,0 +1,175 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xhy/blog-api/config"
+	"github.com/xhy/blog-api/models"
+	"github.com/xhy/blog-api/trash"
+	"gorm.io/gorm"
+)
+
+// GetTrash 获取当前用户回收站中的文章，最近删除的在前
+func GetTrash(c *gin.Context) {
+	var posts []models.Post
+
+	// 获取当前用户ID
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.Response{
+			Code:    http.StatusUnauthorized,
+			Message: "未授权",
+		})
+		return
+	}
+
+	// 分页参数
+	pageSize, offset := parsePagination(c)
+
+	if err := config.DB.Unscoped().
+		Select("id", "title", "slug", "comment_count", "created_at", "deleted_at").
+		Where("user_id = ? AND deleted_at IS NOT NULL", userID).
+		Order("deleted_at desc").Limit(pageSize).Offset(offset).
+		Find(&posts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "获取回收站失败: " + err.Error(),
+		})
+		return
+	}
+
+	retention := config.GetConfig().Trash.Retention()
+	data := make([]models.TrashItem, 0, len(posts))
+	for _, post := range posts {
+		data = append(data, models.TrashItem{
+			ID:           post.ID,
+			Title:        post.Title,
+			Slug:         post.Slug,
+			CommentCount: post.CommentCount,
+			CreatedAt:    post.CreatedAt,
+			DeletedAt:    post.DeletedAt.Time,
+			PurgeAt:      trash.PurgeAt(post.DeletedAt.Time, retention),
+		})
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Code:    http.StatusOK,
+		Message: "获取回收站成功",
+		Data:    data,
+	})
+}
+
+// RestorePost 从回收站恢复文章
+func RestorePost(c *gin.Context) {
+	post, ok := findTrashedPost(c, "没有权限恢复此文章")
+	if !ok {
+		return
+	}
+
+	if err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := models.RestorePost(tx, &post); err != nil {
+			return err
+		}
+		return recordAudit(c, tx, models.AuditRestore, models.TargetPost, post.ID, nil, nil)
+	}); err != nil {
+		// 并发请求已经恢复或彻底删除了这篇文章
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, models.Response{
+				Code:    http.StatusNotFound,
+				Message: "回收站中没有此文章",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "恢复文章失败: " + err.Error(),
+		})
+		return
+	}
+
+	sitemapPut(&post)
+	invalidatePost(c, post.ID)
+
+	post.HideRendered()
+	c.JSON(http.StatusOK, models.Response{
+		Code:    http.StatusOK,
+		Message: "恢复文章成功",
+		Data:    post,
+	})
+}
+
+// PurgePost 彻底删除回收站中的文章，同时删除评论和附件，无法恢复
+func PurgePost(c *gin.Context) {
+	post, ok := findTrashedPost(c, "没有权限删除此文章")
+	if !ok {
+		return
+	}
+
+	event := auditEvent(c, models.AuditPurge, models.TargetPost, post.ID, &post, nil)
+	if err := trash.PurgePost(c.Request.Context(), post.ID, event); err != nil {
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "彻底删除文章失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Code:    http.StatusOK,
+		Message: "彻底删除文章成功",
+	})
+}
+
+// findTrashedPost 查询当前用户回收站中的文章，失败时写入响应
+func findTrashedPost(c *gin.Context, forbidden string) (models.Post, bool) {
+	var post models.Post
+
+	// 获取当前用户ID
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.Response{
+			Code:    http.StatusUnauthorized,
+			Message: "未授权",
+		})
+		return post, false
+	}
+
+	// 查询文章，包括已删除的
+	id, ok := parseID(c, "id", c.Param("id"))
+	if !ok {
+		return post, false
+	}
+	if err := config.DB.Unscoped().First(&post, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, models.Response{
+				Code:    http.StatusNotFound,
+				Message: "回收站中没有此文章",
+			})
+			return post, false
+		}
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Code:    http.StatusInternalServerError,
+			Message: "获取文章失败: " + err.Error(),
+		})
+		return post, false
+	}
+
+	// 检查是否为文章作者，不在回收站中的文章对作者同样按不存在处理
+	if post.UserID != userID.(uint) {
+		c.JSON(http.StatusForbidden, models.Response{
+			Code:    http.StatusForbidden,
+			Message: forbidden,
+		})
+		return post, false
+	}
+	if !post.DeletedAt.Valid {
+		c.JSON(http.StatusNotFound, models.Response{
+			Code:    http.StatusNotFound,
+			Message: "回收站中没有此文章",
+		})
+		return post, false
+	}
+
+	return post, true
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/xhy/blog-api/config"
 	"github.com/xhy/blog-api/models"
 	"github.com/xhy/blog-api/utils"
+	"gorm.io/gorm"
 )
 
 // Register 用户注册
@@ -22,9 +23,9 @@ func Register(c *gin.Context) {
 		return
 	}
 
-	// 检查用户名是否已存在
+	// 检查用户名是否已存在，已删除用户的用户名和邮箱同样不能使用
 	var existingUser models.User
-	if err := config.DB.Where("username = ?", input.Username).First(&existingUser).Error; err == nil {
+	if err := config.DB.Unscoped().Where("username = ?", input.Username).First(&existingUser).Error; err == nil {
 		c.JSON(http.StatusBadRequest, models.Response{
 			Code:    http.StatusBadRequest,
 			Message: "用户名已存在",
@@ -33,7 +34,7 @@ func Register(c *gin.Context) {
 	}
 
 	// 检查邮箱是否已存在
-	if err := config.DB.Where("email = ?", input.Email).First(&existingUser).Error; err == nil {
+	if err := config.DB.Unscoped().Where("email = ?", input.Email).First(&existingUser).Error; err == nil {
 		c.JSON(http.StatusBadRequest, models.Response{
 			Code:    http.StatusBadRequest,
 			Message: "邮箱已存在",
@@ -58,7 +59,16 @@ func Register(c *gin.Context) {
 		Email:    input.Email,
 	}
 
-	if err := config.DB.Create(&user).Error; err != nil {
+	// 注册的用户即为审计日志的执行者
+	if err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		event := auditEvent(c, models.AuditCreate, models.TargetUser, user.ID, nil, &user)
+		event.ActorID = &user.ID
+		event.ActorName = user.Username
+		return tx.Create(&event).Error
+	}); err != nil {
 		c.JSON(http.StatusInternalServerError, models.Response{
 			Code:    http.StatusInternalServerError,
 			Message: "用户创建失败: " + err.Error(),
@@ -104,6 +114,15 @@ func Login(c *gin.Context) {
 		return
 	}
 
+	// 被停用的用户不能登录
+	if user.IsSuspended() {
+		c.JSON(http.StatusForbidden, models.Response{
+			Code:    http.StatusForbidden,
+			Message: "账号已被停用",
+		})
+		return
+	}
+
 	// 生成JWT令牌
 	token, err := utils.GenerateToken(user.ID, user.Username)
 	if err != nil {
@@ -121,4 +140,4 @@ func Login(c *gin.Context) {
 			Token: token,
 		},
 	})
-} 
\ No newline at end of file
+}
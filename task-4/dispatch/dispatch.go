@@ -0,0 +1,121 @@
+package dispatch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+// 跟随重定向的最大次数，用旧别名获取文章时会重定向到当前别名
+const maxRedirects = 3
+
+// Error REST接口返回的错误，保留HTTP状态码和响应中的data
+type Error struct {
+	Status  int
+	Message string
+	Data    json.RawMessage
+}
+
+// Error 错误信息
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Request 交给REST接口处理的请求
+type Request struct {
+	Method     string
+	Path       string      // 可以包含查询参数
+	Header     http.Header // 认证信息、客户端IP和请求ID等，Content-Type由Do设置
+	RemoteAddr string
+	Body       interface{} // 编码为JSON的请求体，为nil时没有请求体
+}
+
+// recorder 记录REST接口的响应
+type recorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+// Header 响应头
+func (r *recorder) Header() http.Header {
+	return r.header
+}
+
+// Write 写入响应体
+func (r *recorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.body.Write(b)
+}
+
+// WriteHeader 写入状态码
+func (r *recorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
+
+// Do 在进程内把请求交给REST路由处理，认证、权限范围、内容过滤、审计日志和缓存失效与REST请求完全一致
+// 成功时把models.Response中的data解码到out（可以为nil），失败时返回*Error
+func Do(ctx context.Context, handler http.Handler, r Request, out interface{}) error {
+	var body []byte
+	if r.Body != nil {
+		b, err := json.Marshal(r.Body)
+		if err != nil {
+			return err
+		}
+		body = b
+	}
+
+	path := r.Path
+	for redirects := 0; ; redirects++ {
+		var reader io.Reader
+		if body != nil {
+			reader = bytes.NewReader(body)
+		}
+		req, err := http.NewRequestWithContext(ctx, r.Method, path, reader)
+		if err != nil {
+			return err
+		}
+		for name, values := range r.Header {
+			req.Header[name] = values
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.RemoteAddr = r.RemoteAddr
+
+		rec := &recorder{header: make(http.Header)}
+		handler.ServeHTTP(rec, req)
+
+		// 只跟随GET请求的重定向，重定向到的仍然是本服务的路由
+		location := rec.header.Get("Location")
+		if r.Method == http.MethodGet && location != "" && redirects < maxRedirects &&
+			(rec.status == http.StatusMovedPermanently || rec.status == http.StatusFound ||
+				rec.status == http.StatusTemporaryRedirect || rec.status == http.StatusPermanentRedirect) {
+			path = location
+			continue
+		}
+		return decode(rec, out)
+	}
+}
+
+// decode 解析与models.Response相同格式的响应，data留到确认成功后再解码
+func decode(rec *recorder, out interface{}) error {
+	var resp struct {
+		Message string          `json:"message"`
+		Data    json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(rec.body.Bytes(), &resp); err != nil {
+		return &Error{Status: rec.status, Message: http.StatusText(rec.status)}
+	}
+	if rec.status >= http.StatusBadRequest {
+		return &Error{Status: rec.status, Message: resp.Message, Data: resp.Data}
+	}
+	if out != nil && len(resp.Data) > 0 {
+		return json.Unmarshal(resp.Data, out)
+	}
+	return nil
+}
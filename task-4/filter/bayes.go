@@ -0,0 +1,175 @@
+package filter
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/xhy/blog-api/config"
+	"github.com/xhy/blog-api/models"
+	"golang.org/x/text/unicode/norm"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 每篇内容最多使用的词数
+const maxTokens = 200
+
+// Bayes 朴素贝叶斯垃圾内容分类器，用版主对评论的审核结果训练：
+// 发布的评论为正常样本，标记为垃圾评论的为垃圾样本，拒绝的评论不参与训练
+type Bayes struct {
+	MinDocs     int
+	HoldAbove   float64
+	RejectAbove float64
+}
+
+// Name 过滤器名称
+func (b *Bayes) Name() string {
+	return "bayes"
+}
+
+// Check 计算内容为垃圾内容的概率，训练样本不足时不做判断
+func (b *Bayes) Check(ctx context.Context, content *Content) (Verdict, error) {
+	tokens := Tokens(content.Text)
+	if len(tokens) == 0 {
+		return Verdict{Action: Allow}, nil
+	}
+
+	var rows []models.SpamToken
+	if err := config.DB.WithContext(ctx).
+		Where("token IN ?", append(tokens, models.SpamDocumentsToken)).
+		Find(&rows).Error; err != nil {
+		return Verdict{}, err
+	}
+
+	var docs models.SpamToken
+	for _, row := range rows {
+		if row.Token == models.SpamDocumentsToken {
+			docs = row
+		}
+	}
+	if docs.Spam < int64(b.MinDocs) || docs.Ham < int64(b.MinDocs) {
+		return Verdict{Action: Allow}, nil
+	}
+
+	// 在对数空间累加各个词的似然比，使用拉普拉斯平滑，没有出现过的词不参与计算
+	logRatio := math.Log(float64(docs.Spam)) - math.Log(float64(docs.Ham))
+	for _, row := range rows {
+		if row.Token == models.SpamDocumentsToken {
+			continue
+		}
+		pSpam := float64(max(row.Spam, 0)+1) / float64(docs.Spam+2)
+		pHam := float64(max(row.Ham, 0)+1) / float64(docs.Ham+2)
+		logRatio += math.Log(pSpam) - math.Log(pHam)
+	}
+	p := 1 / (1 + math.Exp(-logRatio))
+
+	switch {
+	case p > b.RejectAbove:
+		return Verdict{Action: Reject, Reason: fmt.Sprintf("疑似垃圾内容（%.0f%%）", p*100)}, nil
+	case p > b.HoldAbove:
+		return Verdict{Action: Hold, Reason: fmt.Sprintf("疑似垃圾内容（%.0f%%）", p*100)}, nil
+	}
+	return Verdict{Action: Allow}, nil
+}
+
+// Learn 根据评论审核后的状态训练分类器，在修改状态的同一事务中调用
+// 评论的TrainedAs记录实际计入的样本类别，状态变化时只撤销真正计入过的训练，
+// 自动发布、从未参与训练的评论不会被扣减；重复审核不会重复计数
+func Learn(tx *gorm.DB, comment *models.Comment, status string) error {
+	var label string
+	switch status {
+	case models.CommentSpam, models.CommentApproved:
+		label = status
+	}
+	if label == comment.TrainedAs {
+		return nil
+	}
+
+	var spam, ham int
+	switch comment.TrainedAs {
+	case models.CommentSpam:
+		spam--
+	case models.CommentApproved:
+		ham--
+	}
+	switch label {
+	case models.CommentSpam:
+		spam++
+	case models.CommentApproved:
+		ham++
+	}
+
+	tokens := append(Tokens(comment.Content), models.SpamDocumentsToken)
+	rows := make([]models.SpamToken, len(tokens))
+	for i, token := range tokens {
+		rows[i] = models.SpamToken{Token: token, Spam: int64(max(spam, 0)), Ham: int64(max(ham, 0))}
+	}
+	// 计数不会小于0，避免概率为0时对数变为负无穷
+	if err := tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "token"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"spam": gorm.Expr("GREATEST(spam + ?, 0)", spam),
+			"ham":  gorm.Expr("GREATEST(ham + ?, 0)", ham),
+		}),
+	}).Create(&rows).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Model(comment).UpdateColumn("trained_as", label).Error; err != nil {
+		return err
+	}
+	comment.TrainedAs = label
+	return nil
+}
+
+// Tokens 将文本切分为去重的词：连续的汉字切分为相邻两字的组合，
+// 其他文字按空白和标点切分为单词
+func Tokens(text string) []string {
+	seen := make(map[string]bool)
+	var tokens []string
+	add := func(token string) {
+		if len(tokens) < maxTokens && !seen[token] {
+			seen[token] = true
+			tokens = append(tokens, token)
+		}
+	}
+
+	var han []rune
+	var word strings.Builder
+	flush := func() {
+		if len(han) == 1 {
+			add(string(han))
+		}
+		for i := 0; i+1 < len(han); i++ {
+			add(string(han[i : i+2]))
+		}
+		han = han[:0]
+
+		if n := len([]rune(word.String())); n >= 2 && n <= 32 {
+			add(word.String())
+		}
+		word.Reset()
+	}
+
+	for _, r := range norm.NFKC.String(text) {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			if word.Len() > 0 {
+				flush()
+			}
+			han = append(han, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if len(han) > 0 {
+				flush()
+			}
+			word.WriteRune(unicode.ToLower(r))
+		default:
+			flush()
+		}
+	}
+	flush()
+	return tokens
+}
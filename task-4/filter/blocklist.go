@@ -0,0 +1,84 @@
+package filter
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// 至少包含这么多汉字的屏蔽词才按拼音匹配，避免单字同音误伤
+const phoneticMinHan = 2
+
+// keyword 规范化后的屏蔽词
+type keyword struct {
+	raw      string
+	norm     string
+	phonetic string // 为空时不按拼音匹配
+}
+
+// Blocklist 屏蔽词过滤器，命中时拒绝
+type Blocklist struct {
+	keywords []keyword
+	patterns []*regexp.Regexp
+}
+
+// NewBlocklist 创建屏蔽词过滤器，以re:开头的条目按正则表达式匹配原文
+func NewBlocklist(entries []string) (*Blocklist, error) {
+	b := &Blocklist{}
+	for _, entry := range entries {
+		if expr, ok := strings.CutPrefix(entry, "re:"); ok {
+			re, err := regexp.Compile(expr)
+			if err != nil {
+				return nil, fmt.Errorf("屏蔽词正则表达式 %q 无效: %w", expr, err)
+			}
+			b.patterns = append(b.patterns, re)
+			continue
+		}
+
+		k := keyword{raw: entry, norm: Normalize(entry)}
+		if k.norm == "" {
+			continue
+		}
+		if hanCount(k.norm) >= phoneticMinHan {
+			k.phonetic = Phonetic(k.norm)
+		}
+		b.keywords = append(b.keywords, k)
+	}
+	return b, nil
+}
+
+// Name 过滤器名称
+func (b *Blocklist) Name() string {
+	return "blocklist"
+}
+
+// Check 检查内容是否包含屏蔽词
+func (b *Blocklist) Check(ctx context.Context, content *Content) (Verdict, error) {
+	for _, re := range b.patterns {
+		if re.MatchString(content.Text) {
+			return Verdict{Action: Reject, Reason: "包含屏蔽内容"}, nil
+		}
+	}
+	if len(b.keywords) == 0 {
+		return Verdict{Action: Allow}, nil
+	}
+
+	text := Normalize(content.Text)
+	var phonetic string
+	for _, k := range b.keywords {
+		if strings.Contains(text, k.norm) {
+			return Verdict{Action: Reject, Reason: "包含屏蔽词"}, nil
+		}
+		if k.phonetic == "" {
+			continue
+		}
+		if phonetic == "" {
+			phonetic = Phonetic(text)
+		}
+		if strings.Contains(phonetic, k.phonetic) {
+			return Verdict{Action: Reject, Reason: "包含屏蔽词的变体"}, nil
+		}
+	}
+	return Verdict{Action: Allow}, nil
+}
@@ -0,0 +1,120 @@
+package filter
+
+import (
+	"context"
+	"hash/fnv"
+	"math/bits"
+	"time"
+
+	"github.com/xhy/blog-api/config"
+	"github.com/xhy/blog-api/models"
+)
+
+// 计算SimHash时每个片段包含的字符数
+const shingleSize = 4
+
+// Duplicate 近似重复过滤器，与同一用户最近发布的文章和评论比较，重复时等待审核
+type Duplicate struct {
+	Window   time.Duration
+	Recent   int
+	Distance int
+	MinRunes int
+}
+
+// Name 过滤器名称
+func (d *Duplicate) Name() string {
+	return "duplicate"
+}
+
+// Check 计算内容的SimHash，与最近内容的海明距离不超过Distance时视为重复
+func (d *Duplicate) Check(ctx context.Context, content *Content) (Verdict, error) {
+	text := Normalize(content.Text)
+	if d.Recent <= 0 || len([]rune(text)) < d.MinRunes {
+		return Verdict{Action: Allow}, nil
+	}
+	hash := SimHash(text)
+
+	recent, err := d.recentTexts(ctx, content)
+	if err != nil {
+		return Verdict{}, err
+	}
+	for _, other := range recent {
+		if bits.OnesCount64(hash^SimHash(Normalize(other))) <= d.Distance {
+			return Verdict{Action: Hold, Reason: "与最近发布的内容重复"}, nil
+		}
+	}
+	return Verdict{Action: Allow}, nil
+}
+
+// recentTexts 查询用户最近发布的文章和评论，排除正在更新的内容本身
+func (d *Duplicate) recentTexts(ctx context.Context, content *Content) ([]string, error) {
+	since := time.Now().Add(-d.Window)
+	db := config.DB.WithContext(ctx)
+
+	var posts []models.Post
+	query := db.Select("id", "title", "content").
+		Where("user_id = ? AND created_at > ?", content.UserID, since)
+	if content.Kind == KindPost && content.ID != 0 {
+		query = query.Where("id <> ?", content.ID)
+	}
+	if err := query.Order("created_at desc").Limit(d.Recent).Find(&posts).Error; err != nil {
+		return nil, err
+	}
+
+	var comments []models.Comment
+	query = db.Select("id", "content").
+		Where("user_id = ? AND created_at > ?", content.UserID, since)
+	if content.Kind == KindComment && content.ID != 0 {
+		query = query.Where("id <> ?", content.ID)
+	}
+	if err := query.Order("created_at desc").Limit(d.Recent).Find(&comments).Error; err != nil {
+		return nil, err
+	}
+
+	texts := make([]string, 0, len(posts)+len(comments))
+	for _, p := range posts {
+		texts = append(texts, p.Title+"\n"+p.Content)
+	}
+	for _, c := range comments {
+		texts = append(texts, c.Content)
+	}
+	return texts, nil
+}
+
+// SimHash 计算文本的64位SimHash，片段为连续的shingleSize个字符，
+// 相似的文本得到的哈希只有少数位不同
+func SimHash(text string) uint64 {
+	runes := []rune(text)
+	if len(runes) == 0 {
+		return 0
+	}
+
+	var weights [64]int
+	add := func(shingle []rune) {
+		h := fnv.New64a()
+		h.Write([]byte(string(shingle)))
+		sum := h.Sum64()
+		for i := 0; i < 64; i++ {
+			if sum&(1<<uint(i)) != 0 {
+				weights[i]++
+			} else {
+				weights[i]--
+			}
+		}
+	}
+
+	if len(runes) <= shingleSize {
+		add(runes)
+	}
+	for i := 0; i+shingleSize <= len(runes); i++ {
+		add(runes[i : i+shingleSize])
+	}
+
+	var hash uint64
+	for i, w := range weights {
+		if w > 0 {
+			hash |= 1 << uint(i)
+		}
+	}
+	return hash
+}
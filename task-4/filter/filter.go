@@ -0,0 +1,116 @@
+package filter
+
+import (
+	"context"
+	"log"
+
+	"github.com/xhy/blog-api/config"
+)
+
+// Action 过滤结果
+type Action int
+
+const (
+	Allow  Action = iota // 直接发布
+	Hold                 // 等待人工审核
+	Reject               // 拒绝
+)
+
+// String 返回过滤结果的名称
+func (a Action) String() string {
+	switch a {
+	case Hold:
+		return "hold"
+	case Reject:
+		return "reject"
+	}
+	return "allow"
+}
+
+// MarshalText 序列化为名称
+func (a Action) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// 内容类型
+const (
+	KindPost    = "post"
+	KindComment = "comment"
+)
+
+// Content 待检查的内容
+type Content struct {
+	Kind   string
+	ID     uint // 更新已有内容时为内容ID，检查重复时排除自身
+	UserID uint
+	Text   string // 文章为标题和正文，评论为正文
+}
+
+// Verdict 过滤器的判断结果，reason说明被拦截的原因
+type Verdict struct {
+	Action Action `json:"action"`
+	Filter string `json:"filter,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// Filter 内容过滤器
+type Filter interface {
+	Name() string
+	Check(ctx context.Context, content *Content) (Verdict, error)
+}
+
+// Pipeline 依次执行多个过滤器，遇到拒绝时立即返回，否则返回第一个等待审核的结果
+type Pipeline struct {
+	filters []Filter
+}
+
+// NewPipeline 创建过滤流水线
+func NewPipeline(filters ...Filter) *Pipeline {
+	return &Pipeline{filters: filters}
+}
+
+// Default 默认的过滤流水线，Setup之前不做任何过滤
+var Default = NewPipeline()
+
+// Check 检查内容
+func (p *Pipeline) Check(ctx context.Context, content *Content) (Verdict, error) {
+	result := Verdict{Action: Allow}
+	for _, f := range p.filters {
+		verdict, err := f.Check(ctx, content)
+		if err != nil {
+			return Verdict{}, err
+		}
+		if verdict.Action == Allow {
+			continue
+		}
+		verdict.Filter = f.Name()
+		if verdict.Action == Reject {
+			return verdict, nil
+		}
+		if result.Action == Allow {
+			result = verdict
+		}
+	}
+	return result, nil
+}
+
+// Setup 根据配置创建默认的过滤流水线
+func Setup(cfg config.FilterConfig) error {
+	if !cfg.Enabled {
+		Default = NewPipeline()
+		return nil
+	}
+
+	blocklist, err := NewBlocklist(cfg.Blocklist)
+	if err != nil {
+		return err
+	}
+	Default = NewPipeline(
+		blocklist,
+		&LinkLimit{MaxComment: cfg.MaxLinks, MaxPost: cfg.MaxPostLinks},
+		&Duplicate{Window: cfg.DuplicateWindow, Recent: cfg.DuplicateRecent, Distance: cfg.DuplicateDistance, MinRunes: cfg.DuplicateMinRunes},
+		&Bayes{MinDocs: cfg.BayesMinDocs, HoldAbove: cfg.BayesHold, RejectAbove: cfg.BayesReject},
+	)
+	log.Printf("Content filter initialized (%d blocklist entries)", len(cfg.Blocklist))
+	return nil
+}
@@ -0,0 +1,37 @@
+package filter
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+)
+
+// 网址和常见的不带协议的写法
+var linkPattern = regexp.MustCompile(`(?i)\b(?:https?://|ftp://|www\.)[^\s<>()\[\]]+`)
+
+// LinkLimit 链接数量过滤器，链接过多时等待审核
+type LinkLimit struct {
+	MaxComment int // 为0时不限制
+	MaxPost    int
+}
+
+// Name 过滤器名称
+func (l *LinkLimit) Name() string {
+	return "links"
+}
+
+// Check 统计内容中的链接数量
+func (l *LinkLimit) Check(ctx context.Context, content *Content) (Verdict, error) {
+	max := l.MaxComment
+	if content.Kind == KindPost {
+		max = l.MaxPost
+	}
+	if max <= 0 {
+		return Verdict{Action: Allow}, nil
+	}
+
+	if n := len(linkPattern.FindAllStringIndex(content.Text, max+1)); n > max {
+		return Verdict{Action: Hold, Reason: fmt.Sprintf("链接数量超过%d个", max)}, nil
+	}
+	return Verdict{Action: Allow}, nil
+}
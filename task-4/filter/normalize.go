@@ -0,0 +1,50 @@
+package filter
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/mozillazg/go-pinyin"
+	"golang.org/x/text/unicode/norm"
+)
+
+var pinyinArgs = pinyin.NewArgs()
+
+// Normalize 规范化文本用于匹配：全角转半角、兼容字符转为标准形式、转小写，
+// 去掉空白、标点、符号和零宽字符，“微 信”“微★信”“ＷＥＩ”都会变成连续的文字
+func Normalize(text string) string {
+	var b strings.Builder
+	for _, r := range norm.NFKC.String(text) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(unicode.ToLower(r))
+		}
+	}
+	return b.String()
+}
+
+// Phonetic 将规范化后的文本中的汉字转为不带声调的拼音并连写，
+// 繁体字、同音字和直接写拼音的变体会得到相同的结果
+func Phonetic(normalized string) string {
+	var b strings.Builder
+	for _, r := range normalized {
+		if unicode.Is(unicode.Han, r) {
+			if py := pinyin.LazyPinyin(string(r), pinyinArgs); len(py) > 0 {
+				b.WriteString(py[0])
+				continue
+			}
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// hanCount 统计汉字数量
+func hanCount(s string) int {
+	n := 0
+	for _, r := range s {
+		if unicode.Is(unicode.Han, r) {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,61 @@
+package graph
+
+import (
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/xhy/blog-api/dispatch"
+)
+
+// 转发给REST接口时沿用的请求头：认证信息
+// 客户端IP按受信任代理的规则解析后作为对端地址传递，不转发客户端自行设置的X-Forwarded-For
+var forwardedHeaders = []string{"Authorization"}
+
+// apiError REST接口返回的错误，作为GraphQL错误返回，extensions中包含HTTP状态码
+type apiError struct {
+	err *dispatch.Error
+}
+
+// Error 错误信息
+func (e *apiError) Error() string {
+	return e.err.Message
+}
+
+// Extensions GraphQL错误的扩展字段
+func (e *apiError) Extensions() map[string]interface{} {
+	ext := map[string]interface{}{"code": e.err.Status}
+	if len(e.err.Data) > 0 && string(e.err.Data) != "null" {
+		ext["data"] = e.err.Data
+	}
+	return ext
+}
+
+// dispatch 把变更交给对应的REST接口处理
+// 成功时把响应中的data解码到out（可以为nil），失败时返回apiError
+func (r *request) dispatch(method, path string, body interface{}, out interface{}) error {
+	header := make(http.Header)
+	for _, name := range forwardedHeaders {
+		if value := r.gin.GetHeader(name); value != "" {
+			header.Set(name, value)
+		}
+	}
+	// 沿用GraphQL请求的请求ID，审计日志中的记录可以对应到同一个请求
+	if id := r.gin.GetString("requestID"); id != "" {
+		header.Set("X-Request-ID", id)
+	}
+
+	err := dispatch.Do(r.ctx, r.engine, dispatch.Request{
+		Method:     method,
+		Path:       path,
+		Header:     header,
+		RemoteAddr: net.JoinHostPort(r.gin.ClientIP(), "0"),
+		Body:       body,
+	}, out)
+
+	var restErr *dispatch.Error
+	if errors.As(err, &restErr) {
+		return &apiError{restErr}
+	}
+	return err
+}
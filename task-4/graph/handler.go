@@ -0,0 +1,62 @@
+package graph
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+	"github.com/xhy/blog-api/config"
+)
+
+// Params GraphQL请求参数
+type Params struct {
+	Query         string                 `json:"query" binding:"required"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// Handler GraphQL接口，engine用于把变更转发给REST路由
+// 响应使用GraphQL标准格式{data, errors}，无法执行的请求（语法错误、校验失败、超过限制）返回400
+func Handler(engine *gin.Engine) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var params Params
+		if err := c.ShouldBindJSON(&params); err != nil {
+			c.JSON(http.StatusBadRequest, &graphql.Result{Errors: []gqlerrors.FormattedError{
+				gqlerrors.NewFormattedError("无效的请求参数: " + err.Error()),
+			}})
+			return
+		}
+
+		doc, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{
+			Body: []byte(params.Query),
+			Name: "GraphQL request",
+		})})
+		if err != nil {
+			c.JSON(http.StatusBadRequest, &graphql.Result{Errors: gqlerrors.FormatErrors(err)})
+			return
+		}
+
+		if validation := graphql.ValidateDocument(&Schema, doc, nil); !validation.IsValid {
+			c.JSON(http.StatusBadRequest, &graphql.Result{Errors: validation.Errors})
+			return
+		}
+
+		if err := checkLimits(config.GetConfig().GraphQL, doc, params.OperationName, params.Variables); err != nil {
+			c.JSON(http.StatusBadRequest, &graphql.Result{Errors: gqlerrors.FormatErrors(err)})
+			return
+		}
+
+		result := graphql.Execute(graphql.ExecuteParams{
+			Schema:        Schema,
+			AST:           doc,
+			OperationName: params.OperationName,
+			Args:          params.Variables,
+			Context:       context.WithValue(c.Request.Context(), requestKey{}, newRequest(c, engine)),
+		})
+		c.JSON(http.StatusOK, result)
+	}
+}
@@ -0,0 +1,140 @@
+package graph
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/xhy/blog-api/config"
+)
+
+// 列表字段中表示返回数量的参数，复杂度按参数值倍增
+var listSizeArguments = []string{"pageSize", "first"}
+
+// 复杂度的计算上限，超过后不再增长，避免溢出为负数绕过限制
+const complexityCeiling = math.MaxInt32
+
+// listFields 返回列表的字段，没有数量参数时按配置的ListSize估算
+var listFields = map[string]bool{
+	"posts":    true,
+	"comments": true,
+}
+
+// limiter 计算查询的嵌套深度和复杂度，在执行前拒绝过大的查询
+type limiter struct {
+	cfg       config.GraphQLConfig
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+}
+
+// checkLimits 检查要执行的操作是否超过深度和复杂度限制，文档须已通过校验（没有循环引用的片段）
+func checkLimits(cfg config.GraphQLConfig, doc *ast.Document, operationName string, variables map[string]interface{}) error {
+	l := &limiter{cfg: cfg, fragments: make(map[string]*ast.FragmentDefinition), variables: variables}
+
+	var operation *ast.OperationDefinition
+	for _, def := range doc.Definitions {
+		switch def := def.(type) {
+		case *ast.FragmentDefinition:
+			l.fragments[def.Name.Value] = def
+		case *ast.OperationDefinition:
+			if operationName == "" || (def.Name != nil && def.Name.Value == operationName) {
+				operation = def
+			}
+		}
+	}
+	if operation == nil {
+		return nil
+	}
+
+	depth, complexity := l.measure(operation.SelectionSet)
+	if cfg.MaxDepth > 0 && depth > cfg.MaxDepth {
+		return fmt.Errorf("查询嵌套深度 %d 超过上限 %d", depth, cfg.MaxDepth)
+	}
+	if cfg.MaxComplexity > 0 && complexity > cfg.MaxComplexity {
+		return fmt.Errorf("查询复杂度 %d 超过上限 %d", complexity, cfg.MaxComplexity)
+	}
+	return nil
+}
+
+// measure 返回选择集的最大深度和复杂度，片段展开后计算
+// 内省字段（__schema、__type等）的结构由schema决定，不计入限制
+func (l *limiter) measure(set *ast.SelectionSet) (depth, complexity int) {
+	if set == nil {
+		return 0, 0
+	}
+
+	for _, selection := range set.Selections {
+		var d, c int
+		switch s := selection.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(s.Name.Value, "__") {
+				continue
+			}
+			childDepth, childComplexity := l.measure(s.SelectionSet)
+			d = childDepth + 1
+			c = saturatingAdd(1, saturatingMul(l.listSize(s), childComplexity))
+		case *ast.InlineFragment:
+			d, c = l.measure(s.SelectionSet)
+		case *ast.FragmentSpread:
+			if fragment, ok := l.fragments[s.Name.Value]; ok {
+				d, c = l.measure(fragment.SelectionSet)
+			}
+		}
+		depth = max(depth, d)
+		complexity = saturatingAdd(complexity, c)
+	}
+	return depth, complexity
+}
+
+// listSize 列表字段的子字段被重复的次数，非列表字段为1
+// 数量参数不超过解析时允许的maxPageSize
+func (l *limiter) listSize(field *ast.Field) int {
+	for _, arg := range field.Arguments {
+		for _, name := range listSizeArguments {
+			if arg.Name.Value == name {
+				if n, ok := l.intValue(arg.Value); ok && n > 0 {
+					return min(n, maxPageSize)
+				}
+			}
+		}
+	}
+	if listFields[field.Name.Value] {
+		return max(l.cfg.ListSize, 1)
+	}
+	return 1
+}
+
+// intValue 读取整数参数，参数可以是字面量或变量
+func (l *limiter) intValue(value ast.Value) (int, bool) {
+	switch v := value.(type) {
+	case *ast.IntValue:
+		n, err := strconv.Atoi(v.Value)
+		return n, err == nil
+	case *ast.Variable:
+		switch n := l.variables[v.Name.Value].(type) {
+		case float64:
+			return int(math.Min(n, math.MaxInt32)), true
+		case int:
+			return n, true
+		}
+	}
+	return 0, false
+}
+
+// saturatingAdd 非负数相加，结果不超过complexityCeiling
+func saturatingAdd(a, b int) int {
+	if a > complexityCeiling-b {
+		return complexityCeiling
+	}
+	return a + b
+}
+
+// saturatingMul 非负数相乘，结果不超过complexityCeiling
+func saturatingMul(a, b int) int {
+	if a != 0 && b > complexityCeiling/a {
+		return complexityCeiling
+	}
+	return a * b
+}
@@ -0,0 +1,64 @@
+package graph
+
+import "sync"
+
+// loader 按DataLoader的方式批量加载数据：同一层级的字段先登记要加载的键，
+// 第一个取值的字段把登记的键合并成一次查询，避免逐条查询的N+1问题
+// 结果在一次请求内缓存，每个请求使用新的loader
+type loader[K comparable, V any] struct {
+	fetch func(keys []K) (map[K]V, error)
+
+	mu      sync.Mutex
+	pending []K
+	queued  map[K]bool
+	results map[K]V
+	err     error
+}
+
+// newLoader 创建loader，fetch返回的结果中没有的键视为不存在
+func newLoader[K comparable, V any](fetch func(keys []K) (map[K]V, error)) *loader[K, V] {
+	return &loader[K, V]{
+		fetch:   fetch,
+		queued:  make(map[K]bool),
+		results: make(map[K]V),
+	}
+}
+
+// load 登记要加载的键，返回延迟取值的函数，由GraphQL执行器在同一层级的字段都登记后调用
+func (l *loader[K, V]) load(key K) func() (interface{}, error) {
+	l.mu.Lock()
+	if !l.queued[key] {
+		l.queued[key] = true
+		l.pending = append(l.pending, key)
+	}
+	l.mu.Unlock()
+
+	return func() (interface{}, error) {
+		value, ok, err := l.get(key)
+		if err != nil || !ok {
+			return nil, err
+		}
+		return value, nil
+	}
+}
+
+// get 返回键对应的结果，还有未加载的键时先批量加载
+func (l *loader[K, V]) get(key K) (V, bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.pending) > 0 {
+		keys := l.pending
+		l.pending = nil
+		results, err := l.fetch(keys)
+		if err != nil {
+			l.err = err
+		}
+		for k, v := range results {
+			l.results[k] = v
+		}
+	}
+
+	value, ok := l.results[key]
+	return value, ok, l.err
+}
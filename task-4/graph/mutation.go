@@ -0,0 +1,267 @@
+package graph
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/graphql-go/graphql"
+	"github.com/xhy/blog-api/models"
+)
+
+var postInputType = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "PostInput",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"title":      &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+		"content":    &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+		"format":     &graphql.InputObjectFieldConfig{Type: graphql.String, Description: "markdown或plain"},
+		"slug":       &graphql.InputObjectFieldConfig{Type: graphql.String},
+		"moderation": &graphql.InputObjectFieldConfig{Type: graphql.String, Description: "评论审核策略，default表示使用全站策略"},
+	},
+})
+
+var reactionTargetType = graphql.NewEnum(graphql.EnumConfig{
+	Name: "ReactionTarget",
+	Values: graphql.EnumValueConfigMap{
+		"POST":    &graphql.EnumValueConfig{Value: "posts"},
+		"COMMENT": &graphql.EnumValueConfig{Value: "comments"},
+	},
+})
+
+// 常用的参数定义
+var (
+	idArgument       = &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)}
+	stringArgument   = &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)}
+	optionalArgument = &graphql.ArgumentConfig{Type: graphql.String}
+)
+
+// mutationType 变更与REST接口一一对应，由REST接口完成认证、权限范围检查和业务处理
+var mutationType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Mutation",
+	Fields: graphql.Fields{
+		"register": &graphql.Field{
+			Type: graphql.Boolean,
+			Args: graphql.FieldConfigArgument{"username": stringArgument, "password": stringArgument, "email": stringArgument},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return done(fromContext(p.Context).dispatch(http.MethodPost, "/api/register", p.Args, nil))
+			},
+		},
+		"login": &graphql.Field{
+			Type:        graphql.String,
+			Description: "返回JWT令牌",
+			Args:        graphql.FieldConfigArgument{"username": stringArgument, "password": stringArgument},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				var token models.TokenResponse
+				if err := fromContext(p.Context).dispatch(http.MethodPost, "/api/login", p.Args, &token); err != nil {
+					return nil, err
+				}
+				return token.Token, nil
+			},
+		},
+		"createPost": &graphql.Field{
+			Type: postType,
+			Args: graphql.FieldConfigArgument{"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(postInputType)}},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				var post models.Post
+				if err := fromContext(p.Context).dispatch(http.MethodPost, "/api/posts", p.Args["input"], &post); err != nil {
+					return nil, err
+				}
+				return &post, nil
+			},
+		},
+		"updatePost": &graphql.Field{
+			Type: postType,
+			Args: graphql.FieldConfigArgument{"id": idArgument, "input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(postInputType)}},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				id, err := idPath(p.Args, "id")
+				if err != nil {
+					return nil, err
+				}
+				var post models.Post
+				if err := fromContext(p.Context).dispatch(http.MethodPut, "/api/posts/"+id, p.Args["input"], &post); err != nil {
+					return nil, err
+				}
+				return &post, nil
+			},
+		},
+		"deletePost": &graphql.Field{
+			Type:        graphql.Boolean,
+			Description: "将文章移入回收站",
+			Args:        graphql.FieldConfigArgument{"id": idArgument},
+			Resolve:     routeMutation(http.MethodDelete, "/api/posts/", "id", ""),
+		},
+		"restorePost": &graphql.Field{
+			Type: postType,
+			Args: graphql.FieldConfigArgument{"id": idArgument},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				id, err := idPath(p.Args, "id")
+				if err != nil {
+					return nil, err
+				}
+				var post models.Post
+				if err := fromContext(p.Context).dispatch(http.MethodPost, "/api/posts/"+id+"/restore", nil, &post); err != nil {
+					return nil, err
+				}
+				return &post, nil
+			},
+		},
+		"createComment": &graphql.Field{
+			Type:        commentType,
+			Description: "需要审核的评论返回status为pending",
+			Args: graphql.FieldConfigArgument{
+				"postId":   idArgument,
+				"content":  stringArgument,
+				"parentId": &graphql.ArgumentConfig{Type: graphql.ID},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				postID, err := idPath(p.Args, "postId")
+				if err != nil {
+					return nil, err
+				}
+				input := models.CommentInput{Content: p.Args["content"].(string)}
+				if _, ok := p.Args["parentId"]; ok {
+					parentID, err := idArg(p.Args, "parentId")
+					if err != nil {
+						return nil, err
+					}
+					input.ParentID = &parentID
+				}
+				var comment models.Comment
+				if err := fromContext(p.Context).dispatch(http.MethodPost, "/api/posts/"+postID+"/comments", input, &comment); err != nil {
+					return nil, err
+				}
+				return &comment, nil
+			},
+		},
+		"moderateComments": &graphql.Field{
+			Type: moderationResultType,
+			Args: graphql.FieldConfigArgument{
+				"ids":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.ID)))},
+				"action": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String), Description: "approve、reject或spam"},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				input := models.ModerationInput{Action: p.Args["action"].(string)}
+				for _, value := range p.Args["ids"].([]interface{}) {
+					id, err := idArg(map[string]interface{}{"ids": value}, "ids")
+					if err != nil {
+						return nil, err
+					}
+					input.IDs = append(input.IDs, id)
+				}
+				var result models.ModerationResult
+				if err := fromContext(p.Context).dispatch(http.MethodPost, "/api/moderation/comments", input, &result); err != nil {
+					return nil, err
+				}
+				return result, nil
+			},
+		},
+		"reportPost": &graphql.Field{
+			Type:    graphql.Boolean,
+			Args:    graphql.FieldConfigArgument{"id": idArgument, "reason": stringArgument, "detail": optionalArgument},
+			Resolve: reportMutation("/api/posts/"),
+		},
+		"reportComment": &graphql.Field{
+			Type:    graphql.Boolean,
+			Args:    graphql.FieldConfigArgument{"id": idArgument, "reason": stringArgument, "detail": optionalArgument},
+			Resolve: reportMutation("/api/comments/"),
+		},
+		"addReaction": &graphql.Field{
+			Type: graphql.Boolean,
+			Args: graphql.FieldConfigArgument{
+				"target": &graphql.ArgumentConfig{Type: graphql.NewNonNull(reactionTargetType)},
+				"id":     idArgument,
+				"emoji":  stringArgument,
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				id, err := idPath(p.Args, "id")
+				if err != nil {
+					return nil, err
+				}
+				path := "/api/" + p.Args["target"].(string) + "/" + id + "/reactions"
+				body := models.ReactionInput{Emoji: p.Args["emoji"].(string)}
+				return done(fromContext(p.Context).dispatch(http.MethodPost, path, body, nil))
+			},
+		},
+		"removeReaction": &graphql.Field{
+			Type: graphql.Boolean,
+			Args: graphql.FieldConfigArgument{
+				"target": &graphql.ArgumentConfig{Type: graphql.NewNonNull(reactionTargetType)},
+				"id":     idArgument,
+				"emoji":  stringArgument,
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				id, err := idPath(p.Args, "id")
+				if err != nil {
+					return nil, err
+				}
+				path := "/api/" + p.Args["target"].(string) + "/" + id + "/reactions/" + url.PathEscape(p.Args["emoji"].(string))
+				return done(fromContext(p.Context).dispatch(http.MethodDelete, path, nil, nil))
+			},
+		},
+		"followUser": &graphql.Field{
+			Type:    graphql.Boolean,
+			Args:    graphql.FieldConfigArgument{"id": idArgument},
+			Resolve: routeMutation(http.MethodPost, "/api/users/", "id", "/follow"),
+		},
+		"unfollowUser": &graphql.Field{
+			Type:    graphql.Boolean,
+			Args:    graphql.FieldConfigArgument{"id": idArgument},
+			Resolve: routeMutation(http.MethodDelete, "/api/users/", "id", "/follow"),
+		},
+		"bookmarkPost": &graphql.Field{
+			Type: graphql.Boolean,
+			Args: graphql.FieldConfigArgument{"id": idArgument, "collectionId": &graphql.ArgumentConfig{Type: graphql.ID}},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				id, err := idPath(p.Args, "id")
+				if err != nil {
+					return nil, err
+				}
+				var input models.BookmarkInput
+				if _, ok := p.Args["collectionId"]; ok {
+					collectionID, err := idArg(p.Args, "collectionId")
+					if err != nil {
+						return nil, err
+					}
+					input.CollectionID = &collectionID
+				}
+				return done(fromContext(p.Context).dispatch(http.MethodPost, "/api/posts/"+id+"/bookmark", input, nil))
+			},
+		},
+		"unbookmarkPost": &graphql.Field{
+			Type:    graphql.Boolean,
+			Args:    graphql.FieldConfigArgument{"id": idArgument},
+			Resolve: routeMutation(http.MethodDelete, "/api/posts/", "id", "/bookmark"),
+		},
+	},
+})
+
+// routeMutation 没有请求体、成功时返回true的变更，路径为prefix+ID+suffix
+func routeMutation(method, prefix, arg, suffix string) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		id, err := idPath(p.Args, arg)
+		if err != nil {
+			return nil, err
+		}
+		return done(fromContext(p.Context).dispatch(method, prefix+id+suffix, nil, nil))
+	}
+}
+
+// reportMutation 举报文章或评论
+func reportMutation(prefix string) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		id, err := idPath(p.Args, "id")
+		if err != nil {
+			return nil, err
+		}
+		input := models.ReportInput{Reason: p.Args["reason"].(string)}
+		input.Detail, _ = p.Args["detail"].(string)
+		return done(fromContext(p.Context).dispatch(http.MethodPost, prefix+id+"/report", input, nil))
+	}
+}
+
+// done 没有返回数据的变更，成功时返回true
+func done(err error) (interface{}, error) {
+	if err != nil {
+		return nil, err
+	}
+	return true, nil
+}
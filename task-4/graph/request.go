@@ -0,0 +1,138 @@
+package graph
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xhy/blog-api/config"
+	"github.com/xhy/blog-api/models"
+	"gorm.io/gorm"
+)
+
+// requestKey 在上下文中保存request的键
+type requestKey struct{}
+
+// request 一次GraphQL请求的状态：当前用户和按请求缓存的loader
+type request struct {
+	ctx    context.Context
+	gin    *gin.Context
+	engine *gin.Engine
+	viewer uint // 当前用户ID，未登录时为0
+
+	users        *loader[uint, *models.User]
+	posts        *loader[uint, *models.Post]
+	comments     *loader[uint, *models.Comment]
+	postComments *loader[uint, []models.Comment]
+	followers    *loader[uint, int64]
+	following    *loader[uint, int64]
+}
+
+// newRequest 创建请求状态，OptionalAuthMiddleware已经校验过凭证
+func newRequest(c *gin.Context, engine *gin.Engine) *request {
+	r := &request{ctx: c.Request.Context(), gin: c, engine: engine}
+	if userID, exists := c.Get("userID"); exists {
+		r.viewer = userID.(uint)
+	}
+
+	r.users = newLoader(func(ids []uint) (map[uint]*models.User, error) {
+		var users []models.User
+		if err := r.db().Where("id IN ?", ids).Find(&users).Error; err != nil {
+			return nil, err
+		}
+		results := make(map[uint]*models.User, len(users))
+		for i := range users {
+			results[users[i].ID] = &users[i]
+		}
+		return results, nil
+	})
+
+	r.posts = newLoader(func(ids []uint) (map[uint]*models.Post, error) {
+		var posts []models.Post
+		if err := r.visiblePosts().Where("id IN ?", ids).Find(&posts).Error; err != nil {
+			return nil, err
+		}
+		results := make(map[uint]*models.Post, len(posts))
+		for i := range posts {
+			results[posts[i].ID] = &posts[i]
+		}
+		return results, nil
+	})
+
+	r.comments = newLoader(func(ids []uint) (map[uint]*models.Comment, error) {
+		var comments []models.Comment
+		if err := r.visibleComments().Where("id IN ?", ids).Find(&comments).Error; err != nil {
+			return nil, err
+		}
+		results := make(map[uint]*models.Comment, len(comments))
+		for i := range comments {
+			results[comments[i].ID] = &comments[i]
+		}
+		return results, nil
+	})
+
+	r.postComments = newLoader(func(postIDs []uint) (map[uint][]models.Comment, error) {
+		var comments []models.Comment
+		if err := r.visibleComments().Where("post_id IN ?", postIDs).
+			Order("created_at asc, id asc").Find(&comments).Error; err != nil {
+			return nil, err
+		}
+		results := make(map[uint][]models.Comment, len(postIDs))
+		for _, id := range postIDs {
+			results[id] = []models.Comment{}
+		}
+		for _, comment := range comments {
+			results[comment.PostID] = append(results[comment.PostID], comment)
+		}
+		return results, nil
+	})
+
+	r.followers = newLoader(func(ids []uint) (map[uint]int64, error) {
+		return r.countFollows("followee_id", ids)
+	})
+	r.following = newLoader(func(ids []uint) (map[uint]int64, error) {
+		return r.countFollows("follower_id", ids)
+	})
+	return r
+}
+
+// fromContext 从解析函数的上下文中取出请求状态
+func fromContext(ctx context.Context) *request {
+	return ctx.Value(requestKey{}).(*request)
+}
+
+// db 绑定请求上下文的数据库连接
+func (r *request) db() *gorm.DB {
+	return config.DB.WithContext(r.ctx)
+}
+
+// visiblePosts 当前用户可以看到的文章：与REST接口一致，被举报隐藏的文章只有作者可见
+func (r *request) visiblePosts() *gorm.DB {
+	return r.db().Where("posts.hidden = ? OR posts.user_id = ?", false, r.viewer)
+}
+
+// visibleComments 当前用户可以看到的评论：已发布的评论，以及自己等待审核的评论
+func (r *request) visibleComments() *gorm.DB {
+	return r.db().Where("comments.status = ? OR (comments.status = ? AND comments.user_id = ?)",
+		models.CommentApproved, models.CommentPending, r.viewer)
+}
+
+// countFollows 按column分组统计关注关系数量，没有关注关系的用户为0
+func (r *request) countFollows(column string, ids []uint) (map[uint]int64, error) {
+	var rows []struct {
+		ID    uint
+		Count int64
+	}
+	if err := r.db().Model(&models.Follow{}).
+		Select(column+" AS id, COUNT(*) AS count").
+		Where(column+" IN ?", ids).Group(column).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	results := make(map[uint]int64, len(ids))
+	for _, id := range ids {
+		results[id] = 0
+	}
+	for _, row := range rows {
+		results[row.ID] = row.Count
+	}
+	return results, nil
+}
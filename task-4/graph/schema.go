@@ -0,0 +1,339 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/graphql-go/graphql"
+	"github.com/xhy/blog-api/dispatch"
+	"github.com/xhy/blog-api/models"
+)
+
+// 文章列表每页数量的上限，与REST接口一致
+const maxPageSize = 100
+
+// Schema GraphQL schema：查询直接读取数据库并批量加载关联对象，变更交给对应的REST接口处理
+var Schema graphql.Schema
+
+func init() {
+	// 文章和评论互相引用，在这里补充关联字段，避免包级变量的初始化循环
+	postType.AddFieldConfig("comments", &graphql.Field{
+		Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(commentType))),
+		Description: "已发布的评论和当前用户自己等待审核的评论，最早的在前",
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return fromContext(p.Context).loadPostComments(p.Source.(*models.Post).ID), nil
+		},
+	})
+	commentType.AddFieldConfig("post", &graphql.Field{
+		Type: postType,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return fromContext(p.Context).posts.load(p.Source.(*models.Comment).PostID), nil
+		},
+	})
+	commentType.AddFieldConfig("parent", &graphql.Field{
+		Type:        commentType,
+		Description: "回复的评论",
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			comment := p.Source.(*models.Comment)
+			if comment.ParentID == nil {
+				return nil, nil
+			}
+			return fromContext(p.Context).comments.load(*comment.ParentID), nil
+		},
+	})
+
+	var err error
+	Schema, err = graphql.NewSchema(graphql.SchemaConfig{
+		Query:    queryType,
+		Mutation: mutationType,
+	})
+	if err != nil {
+		panic(fmt.Sprintf("GraphQL schema定义错误: %v", err))
+	}
+}
+
+var userType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "User",
+	Fields: graphql.Fields{
+		"id":       &graphql.Field{Type: graphql.NewNonNull(graphql.ID), Resolve: userField(func(u *models.User) interface{} { return u.ID })},
+		"username": &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: userField(func(u *models.User) interface{} { return u.Username })},
+		"role":     &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: userField(func(u *models.User) interface{} { return u.Role })},
+		"email": &graphql.Field{
+			Type:        graphql.String,
+			Description: "只对用户本人返回",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				u := p.Source.(*models.User)
+				if u.ID != fromContext(p.Context).viewer {
+					return nil, nil
+				}
+				return u.Email, nil
+			},
+		},
+		"createdAt": &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime), Resolve: userField(func(u *models.User) interface{} { return u.CreatedAt })},
+		"postCount": &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Resolve: userField(func(u *models.User) interface{} { return u.PostCount })},
+		"followerCount": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Int),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return fromContext(p.Context).followers.load(p.Source.(*models.User).ID), nil
+			},
+		},
+		"followingCount": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Int),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return fromContext(p.Context).following.load(p.Source.(*models.User).ID), nil
+			},
+		},
+	},
+})
+
+var postType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Post",
+	Fields: graphql.Fields{
+		"id":      &graphql.Field{Type: graphql.NewNonNull(graphql.ID), Resolve: postField(func(p *models.Post) interface{} { return p.ID })},
+		"title":   &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: postField(func(p *models.Post) interface{} { return p.Title })},
+		"slug":    &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: postField(func(p *models.Post) interface{} { return p.Slug })},
+		"content": &graphql.Field{Type: graphql.NewNonNull(graphql.String), Description: "Markdown或纯文本源文", Resolve: postField(func(p *models.Post) interface{} { return p.Content })},
+		"contentHtml": &graphql.Field{
+			Type:        graphql.NewNonNull(graphql.String),
+			Description: "过滤后的HTML",
+			Resolve: postField(func(p *models.Post) interface{} {
+				if p.ContentHTML == "" {
+					p.Render()
+				}
+				return p.ContentHTML
+			}),
+		},
+		"format":       &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: postField(func(p *models.Post) interface{} { return p.Format })},
+		"moderation":   &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: postField(func(p *models.Post) interface{} { return p.Moderation })},
+		"createdAt":    &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime), Resolve: postField(func(p *models.Post) interface{} { return p.CreatedAt })},
+		"updatedAt":    &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime), Resolve: postField(func(p *models.Post) interface{} { return p.UpdatedAt })},
+		"viewCount":    &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Resolve: postField(func(p *models.Post) interface{} { return p.ViewCount })},
+		"commentCount": &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Resolve: postField(func(p *models.Post) interface{} { return p.CommentCount })},
+		"author": &graphql.Field{
+			Type: userType,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return fromContext(p.Context).users.load(p.Source.(*models.Post).UserID), nil
+			},
+		},
+	},
+})
+
+var commentType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Comment",
+	Fields: graphql.Fields{
+		"id":      &graphql.Field{Type: graphql.NewNonNull(graphql.ID), Resolve: commentField(func(c *models.Comment) interface{} { return c.ID })},
+		"content": &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: commentField(func(c *models.Comment) interface{} { return c.Content })},
+		"contentHtml": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.String),
+			Resolve: commentField(func(c *models.Comment) interface{} {
+				if c.ContentHTML == "" {
+					c.Render()
+				}
+				return c.ContentHTML
+			}),
+		},
+		"status":    &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: commentField(func(c *models.Comment) interface{} { return c.Status })},
+		"createdAt": &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime), Resolve: commentField(func(c *models.Comment) interface{} { return c.CreatedAt })},
+		"author": &graphql.Field{
+			Type: userType,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return fromContext(p.Context).users.load(p.Source.(*models.Comment).UserID), nil
+			},
+		},
+	},
+})
+
+var moderationResultType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "ModerationResult",
+	Fields: graphql.Fields{
+		"updated": &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.ID)))},
+		"skipped": &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.ID)))},
+	},
+})
+
+var queryType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Query",
+	Fields: graphql.Fields{
+		"me": &graphql.Field{
+			Type:        userType,
+			Description: "当前登录的用户，未登录时为null",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				r := fromContext(p.Context)
+				if r.viewer == 0 {
+					return nil, nil
+				}
+				return r.users.load(r.viewer), nil
+			},
+		},
+		"user": &graphql.Field{
+			Type: userType,
+			Args: graphql.FieldConfigArgument{"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)}},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				id, err := idArg(p.Args, "id")
+				if err != nil {
+					return nil, err
+				}
+				return fromContext(p.Context).users.load(id), nil
+			},
+		},
+		"post": &graphql.Field{
+			Type:        postType,
+			Description: "按ID或别名获取文章，旧别名同样可以找到文章",
+			Args: graphql.FieldConfigArgument{
+				"id":   &graphql.ArgumentConfig{Type: graphql.ID},
+				"slug": &graphql.ArgumentConfig{Type: graphql.String},
+			},
+			Resolve: resolvePost,
+		},
+		"posts": &graphql.Field{
+			Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(postType))),
+			Description: "文章列表，最新的在前",
+			Args: graphql.FieldConfigArgument{
+				"page":     &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 1},
+				"pageSize": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 10},
+				"userId":   &graphql.ArgumentConfig{Type: graphql.ID},
+			},
+			Resolve: resolvePosts,
+		},
+		"comments": &graphql.Field{
+			Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(commentType))),
+			Description: "文章的评论，最早的在前",
+			Args:        graphql.FieldConfigArgument{"postId": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)}},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				id, err := idArg(p.Args, "postId")
+				if err != nil {
+					return nil, err
+				}
+				r := fromContext(p.Context)
+				if _, ok, err := r.posts.get(id); err != nil || !ok {
+					return nil, errPostNotFound(err)
+				}
+				return r.loadPostComments(id), nil
+			},
+		},
+	},
+})
+
+// resolvePost 按ID或别名获取文章
+func resolvePost(p graphql.ResolveParams) (interface{}, error) {
+	r := fromContext(p.Context)
+	if _, ok := p.Args["id"]; ok {
+		id, err := idArg(p.Args, "id")
+		if err != nil {
+			return nil, err
+		}
+		return r.posts.load(id), nil
+	}
+
+	slug, _ := p.Args["slug"].(string)
+	if slug == "" {
+		return nil, errors.New("需要提供id或slug")
+	}
+	var postSlug models.PostSlug
+	if err := r.db().Where("slug = ?", slug).Limit(1).Find(&postSlug).Error; err != nil {
+		return nil, err
+	}
+	if postSlug.PostID == 0 {
+		return nil, nil
+	}
+	return r.posts.load(postSlug.PostID), nil
+}
+
+// resolvePosts 获取文章列表，与REST接口一样不包含被举报隐藏的文章
+func resolvePosts(p graphql.ResolveParams) (interface{}, error) {
+	r := fromContext(p.Context)
+	page, _ := p.Args["page"].(int)
+	pageSize, _ := p.Args["pageSize"].(int)
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > maxPageSize {
+		pageSize = 10
+	}
+
+	query := r.db().Scopes(models.VisiblePosts)
+	if _, ok := p.Args["userId"]; ok {
+		userID, err := idArg(p.Args, "userId")
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("user_id = ?", userID)
+	}
+
+	var posts []models.Post
+	if err := query.Order("created_at desc").Limit(pageSize).Offset((page - 1) * pageSize).Find(&posts).Error; err != nil {
+		return nil, err
+	}
+	results := make([]*models.Post, len(posts))
+	for i := range posts {
+		results[i] = &posts[i]
+	}
+	return results, nil
+}
+
+// loadPostComments 批量加载文章的评论
+func (r *request) loadPostComments(postID uint) func() (interface{}, error) {
+	thunk := r.postComments.load(postID)
+	return func() (interface{}, error) {
+		value, err := thunk()
+		if err != nil || value == nil {
+			return []*models.Comment{}, err
+		}
+		comments := value.([]models.Comment)
+		results := make([]*models.Comment, len(comments))
+		for i := range comments {
+			results[i] = &comments[i]
+		}
+		return results, nil
+	}
+}
+
+// errPostNotFound 文章不存在或查询失败
+func errPostNotFound(err error) error {
+	if err != nil {
+		return err
+	}
+	return &apiError{&dispatch.Error{Status: http.StatusNotFound, Message: "文章不存在"}}
+}
+
+// idArg 读取ID参数
+func idArg(args map[string]interface{}, name string) (uint, error) {
+	value, _ := args[name].(string)
+	id, err := strconv.ParseUint(value, 10, 64)
+	if err != nil || id == 0 {
+		return 0, fmt.Errorf("%s不是有效的ID: %s", name, value)
+	}
+	return uint(id), nil
+}
+
+// idPath 将ID参数拼接到REST路径中
+func idPath(args map[string]interface{}, name string) (string, error) {
+	id, err := idArg(args, name)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatUint(uint64(id), 10), nil
+}
+
+// userField 读取用户字段的解析函数
+func userField(get func(*models.User) interface{}) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		return get(p.Source.(*models.User)), nil
+	}
+}
+
+// postField 读取文章字段的解析函数
+func postField(get func(*models.Post) interface{}) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		return get(p.Source.(*models.Post)), nil
+	}
+}
+
+// commentField 读取评论字段的解析函数
+func commentField(get func(*models.Comment) interface{}) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		return get(p.Source.(*models.Comment)), nil
+	}
+}
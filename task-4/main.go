@@ -1,22 +1,58 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/xhy/blog-api/config"
+	"github.com/xhy/blog-api/filter"
 	"github.com/xhy/blog-api/models"
 	"github.com/xhy/blog-api/routes"
+	"github.com/xhy/blog-api/rpc"
+	"github.com/xhy/blog-api/trash"
 	"github.com/xhy/blog-api/utils"
+	"github.com/xhy/blog-api/views"
+	"gorm.io/gorm"
 )
 
 func main() {
+	rotateKey := flag.Bool("rotate-jwt-key", false, "生成新的JWT签名密钥后退出")
+	reconcile := flag.Bool("reconcile-counters", false, "重新统计文章数、评论数等计数列，修正偏差后退出")
+	setRole := flag.String("set-role", "", "设置用户角色后退出，格式为 用户名=角色，角色为user、moderator或admin")
+	flag.Parse()
+
 	// 获取配置
 	cfg := config.GetConfig()
 
+	// 轮换JWT签名密钥：新密钥在下次启动时用于签名，旧密钥保留用于验证
+	if *rotateKey {
+		key, err := utils.RotateSigningKey(cfg.JWT.KeyDir, cfg.JWT.Algorithm)
+		if err != nil {
+			log.Fatalf("生成JWT签名密钥失败: %v", err)
+		}
+		log.Printf("已生成JWT签名密钥 %s", key.ID)
+		return
+	}
+
 	// 设置JWT配置
 	utils.SetJWTSecret(cfg.JWT.Secret)
 	utils.SetJWTDuration(cfg.JWT.ExpiresIn)
+	utils.SetJWTIssuer(cfg.JWT.Issuer)
+	utils.SetJWTAudience(cfg.JWT.Audience)
+	utils.SetJWTLeeway(cfg.JWT.Leeway)
+	utils.SetJWTLegacyUntil(cfg.JWT.LegacyUntil)
+	if err := utils.InitJWTKeys(cfg.JWT.Algorithm, cfg.JWT.KeyDir, cfg.JWT.ActiveKID); err != nil {
+		log.Fatalf("加载JWT签名密钥失败: %v", err)
+	}
 
 	// 初始化数据库连接
 	config.InitDB()
@@ -30,22 +66,159 @@ func main() {
 
 	// 自动迁移数据库模型
 	log.Println("开始数据库迁移...")
-	err := config.DB.AutoMigrate(&models.User{}, &models.Post{}, &models.Comment{})
+	err := config.DB.AutoMigrate(
+		&models.User{},
+		&models.Post{},
+		&models.PostSlug{},
+		&models.Comment{},
+		&models.PersonalAccessToken{},
+		&models.Attachment{},
+		&models.Reaction{},
+		&models.BookmarkCollection{},
+		&models.Bookmark{},
+		&models.Follow{},
+		&models.Notification{},
+		&models.SpamToken{},
+		&models.Report{},
+		&models.AuditEvent{},
+	)
 	if err != nil {
 		log.Fatalf("数据库迁移失败: %v", err)
 	}
 	log.Println("数据库迁移完成")
 
+	// 校对计数列
+	if *reconcile {
+		drifts, err := models.ReconcileCounters(config.DB, true)
+		for _, d := range drifts {
+			log.Printf("计数偏差 %s#%d %s: 记录为 %d，实际为 %d", d.Table, d.ID, d.Column, d.Stored, d.Actual)
+		}
+		if err != nil {
+			log.Fatalf("校对计数失败: %v", err)
+		}
+		log.Printf("计数校对完成，修正 %d 条记录", len(drifts))
+		return
+	}
+
+	// 设置用户角色
+	if *setRole != "" {
+		username, role, ok := strings.Cut(*setRole, "=")
+		if !ok || !models.IsValidRole(role) {
+			log.Fatalf("角色格式错误: %s，应为 用户名=user|moderator|admin", *setRole)
+		}
+		var user models.User
+		if err := config.DB.Where("username = ?", username).First(&user).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				log.Fatalf("用户 %s 不存在", username)
+			}
+			log.Fatalf("获取用户失败: %v", err)
+		}
+		previous := user.Role
+		// 命令行操作以系统身份写入审计日志
+		if err := config.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&user).Update("role", role).Error; err != nil {
+				return err
+			}
+			return tx.Create(&models.AuditEvent{
+				ActorName:  models.AuditSystem,
+				Action:     models.AuditUpdate,
+				TargetType: models.TargetUser,
+				TargetID:   user.ID,
+				Changes:    models.AuditDiff(map[string]string{"role": previous}, map[string]string{"role": role}),
+			}).Error
+		}); err != nil {
+			log.Fatalf("设置用户角色失败: %v", err)
+		}
+		log.Printf("已将用户 %s 的角色设置为 %s", username, role)
+		return
+	}
+
+	// 为已有文章生成别名
+	if err := models.BackfillPostSlugs(config.DB); err != nil {
+		log.Fatalf("生成文章别名失败: %v", err)
+	}
+
+	// 初始化附件存储
+	config.InitStorage()
+
+	// 初始化缓存
+	config.InitCache()
+
+	// 初始化内容过滤
+	if err := filter.Setup(cfg.Filter); err != nil {
+		log.Fatalf("初始化内容过滤失败: %v", err)
+	}
+
 	// 初始化示例数据
 	//config.SeedData()
 
-	// 创建Gin实例
-	router := gin.Default()
+	// 启动阅读量定期写入
+	views.Default.SetWindow(cfg.Views.DedupWindow)
+	stopViews := views.Default.Start(cfg.Views.FlushInterval)
+
+	// 启动回收站定期清理
+	stopTrash := trash.Start(cfg.Trash.PurgeInterval, cfg.Trash.Retention())
+
+	// 创建Gin实例，只使用自定义的日志中间件，gin自带的日志会记录带令牌的完整URL
+	router := gin.New()
+	router.Use(gin.Recovery())
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		log.Fatalf("受信任代理配置错误: %v", err)
+	}
 
 	// 设置路由
 	routes.SetupRoutes(router)
 
+	// 关闭服务器时取消所有请求的上下文，让实时推送等长连接及时退出
+	baseCtx, cancelRequests := context.WithCancel(context.Background())
+	srv := &http.Server{
+		Addr:        ":" + cfg.Server.Port,
+		Handler:     router,
+		BaseContext: func(net.Listener) context.Context { return baseCtx },
+	}
+
 	// 启动服务器
-	log.Printf("服务器启动在 http://localhost:%s", cfg.Server.Port)
-	router.Run(":" + cfg.Server.Port)
+	go func() {
+		log.Printf("服务器启动在 http://localhost:%s", cfg.Server.Port)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("服务器启动失败: %v", err)
+		}
+	}()
+
+	// 启动gRPC服务器，调用由同一个路由处理
+	var grpcServer *rpc.Server
+	if cfg.GRPC.Port != "" {
+		lis, err := net.Listen("tcp", ":"+cfg.GRPC.Port)
+		if err != nil {
+			log.Fatalf("gRPC服务器监听失败: %v", err)
+		}
+		grpcServer = rpc.NewServer(router, cfg.GRPC)
+		go func() {
+			log.Printf("gRPC服务器启动在 localhost:%s", cfg.GRPC.Port)
+			if err := grpcServer.Serve(lis); err != nil {
+				log.Fatalf("gRPC服务器启动失败: %v", err)
+			}
+		}()
+	}
+
+	// 收到退出信号后停止接收新请求，等待处理中的请求完成
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+	log.Println("正在关闭服务器...")
+
+	cancelRequests()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("服务器关闭超时: %v", err)
+	}
+	if grpcServer != nil {
+		grpcServer.Stop(10 * time.Second)
+	}
+
+	// 停止回收站清理，写入剩余的阅读量
+	stopTrash()
+	stopViews()
+	log.Println("服务器已关闭")
 }
@@ -0,0 +1,191 @@
+package markdown
+
+import (
+	"bytes"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+// Heading 目录项
+type Heading struct {
+	Level int    `json:"level"`
+	Text  string `json:"text"`
+	ID    string `json:"id"`
+}
+
+var (
+	// 文章：CommonMark + GFM表格、删除线、自动链接，标题自动生成锚点
+	postMarkdown = goldmark.New(
+		goldmark.WithExtensions(extension.Table, extension.Strikethrough, extension.Linkify),
+		goldmark.WithParserOptions(parser.WithAutoHeadingID()),
+	)
+
+	// 评论：只使用CommonMark基础语法，渲染结果再经过更严格的白名单过滤
+	commentMarkdown = goldmark.New(
+		goldmark.WithExtensions(extension.Strikethrough, extension.Linkify),
+	)
+
+	postPolicy    = newPostPolicy()
+	commentPolicy = newCommentPolicy()
+	textPolicy    = bluemonday.StrictPolicy()
+)
+
+// newPostPolicy 文章HTML白名单
+func newPostPolicy() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	p.AllowAttrs("id").Matching(regexp.MustCompile(`^[\p{L}\p{N}_-]+$`)).OnElements("h1", "h2", "h3", "h4", "h5", "h6")
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[\w+#-]+$`)).OnElements("code")
+	return p
+}
+
+// newCommentPolicy 评论HTML白名单，不允许标题、图片和表格
+func newCommentPolicy() *bluemonday.Policy {
+	p := bluemonday.NewPolicy()
+	p.AllowElements("p", "br", "em", "strong", "del", "code", "pre", "blockquote", "ul", "ol", "li")
+	p.AllowStandardURLs()
+	p.AllowAttrs("href").OnElements("a")
+	p.RequireNoFollowOnLinks(true)
+	p.AddTargetBlankToFullyQualifiedLinks(true)
+	return p
+}
+
+// RenderPost 将文章Markdown渲染为经过过滤的HTML，并提取标题目录
+func RenderPost(source string) (string, []Heading) {
+	src := []byte(source)
+	ctx := parser.NewContext(parser.WithIDs(newIDs()))
+	doc := postMarkdown.Parser().Parse(text.NewReader(src), parser.WithContext(ctx))
+
+	var buf bytes.Buffer
+	if err := postMarkdown.Renderer().Render(&buf, src, doc); err != nil {
+		return RenderPlain(source), nil
+	}
+
+	return postPolicy.Sanitize(buf.String()), headings(doc, src)
+}
+
+// RenderComment 使用受限的Markdown子集渲染评论
+func RenderComment(source string) string {
+	var buf bytes.Buffer
+	if err := commentMarkdown.Convert([]byte(source), &buf); err != nil {
+		return RenderPlain(source)
+	}
+	return commentPolicy.Sanitize(buf.String())
+}
+
+// RenderPlain 将纯文本转义为HTML段落，保留换行
+func RenderPlain(source string) string {
+	var buf strings.Builder
+	for _, para := range strings.Split(strings.ReplaceAll(source, "\r\n", "\n"), "\n\n") {
+		para = strings.TrimSpace(para)
+		if para == "" {
+			continue
+		}
+		buf.WriteString("<p>")
+		buf.WriteString(strings.ReplaceAll(html.EscapeString(para), "\n", "<br>"))
+		buf.WriteString("</p>\n")
+	}
+	return buf.String()
+}
+
+// Excerpt 从渲染后的HTML提取纯文本摘要，超过limit个字符时截断并加省略号
+func Excerpt(renderedHTML string, limit int) string {
+	// 块级元素去掉标签后内容会连在一起，先在结束标签前补空格
+	plain := html.UnescapeString(textPolicy.Sanitize(strings.ReplaceAll(renderedHTML, "</", " </")))
+	plain = strings.Join(strings.Fields(plain), " ")
+
+	runes := []rune(plain)
+	if len(runes) <= limit {
+		return plain
+	}
+	return strings.TrimSpace(string(runes[:limit])) + "…"
+}
+
+// headings 遍历语法树提取标题
+func headings(doc ast.Node, source []byte) []Heading {
+	var toc []Heading
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		h, ok := n.(*ast.Heading)
+		if !ok || !entering {
+			return ast.WalkContinue, nil
+		}
+		entry := Heading{Level: h.Level, Text: nodeText(h, source)}
+		if id, ok := h.AttributeString("id"); ok {
+			if b, ok := id.([]byte); ok {
+				entry.ID = string(b)
+			}
+		}
+		toc = append(toc, entry)
+		return ast.WalkSkipChildren, nil
+	})
+	return toc
+}
+
+// nodeText 拼接节点下的纯文本
+func nodeText(n ast.Node, source []byte) string {
+	var buf strings.Builder
+	_ = ast.Walk(n, func(c ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch t := c.(type) {
+		case *ast.Text:
+			buf.Write(t.Segment.Value(source))
+			if t.SoftLineBreak() {
+				buf.WriteByte(' ')
+			}
+		case *ast.String:
+			buf.Write(t.Value)
+		}
+		return ast.WalkContinue, nil
+	})
+	return strings.TrimSpace(buf.String())
+}
+
+// ids 标题锚点生成器
+// goldmark默认会丢弃非ASCII字符，中文标题全部变成heading，这里保留Unicode字母和数字
+type ids struct {
+	values map[string]bool
+}
+
+func newIDs() *ids {
+	return &ids{values: make(map[string]bool)}
+}
+
+// Generate 生成唯一的锚点，重复时追加序号
+func (s *ids) Generate(value []byte, kind ast.NodeKind) []byte {
+	var buf strings.Builder
+	for _, r := range strings.TrimSpace(string(value)) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsNumber(r):
+			buf.WriteRune(unicode.ToLower(r))
+		case unicode.IsSpace(r) || r == '-' || r == '_':
+			buf.WriteByte('-')
+		}
+	}
+	base := buf.String()
+	if base == "" {
+		base = "heading"
+	}
+
+	id := base
+	for i := 1; s.values[id]; i++ {
+		id = base + "-" + strconv.Itoa(i)
+	}
+	s.values[id] = true
+	return []byte(id)
+}
+
+// Put 记录已使用的锚点
+func (s *ids) Put(value []byte) {
+	s.values[string(value)] = true
+}
@@ -3,13 +3,19 @@ package middleware
 import (
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/xhy/blog-api/config"
 	"github.com/xhy/blog-api/models"
 	"github.com/xhy/blog-api/utils"
+	"gorm.io/gorm"
 )
 
-// AuthMiddleware 认证中间件
+// 访问令牌最后使用时间的更新间隔，避免每个请求都写数据库
+const tokenTouchInterval = time.Minute
+
+// AuthMiddleware 认证中间件，同时支持JWT和个人访问令牌
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 从请求头获取Authorization
@@ -34,20 +40,187 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		// 解析JWT令牌
-		claims, err := utils.ParseToken(parts[1])
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, models.Response{
-				Code:    http.StatusUnauthorized,
-				Message: "无效的认证令牌",
+		if status, message := authenticate(c, parts[1]); status != http.StatusOK {
+			c.JSON(status, models.Response{
+				Code:    status,
+				Message: message,
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// OptionalAuthMiddleware 可选认证中间件，用于公开路由
+// 携带有效凭证时存储用户信息，没有凭证或凭证无效时按匿名用户继续处理
+func OptionalAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		parts := strings.SplitN(c.GetHeader("Authorization"), " ", 2)
+		if len(parts) == 2 && parts[0] == "Bearer" {
+			authenticate(c, parts[1])
+		}
+		c.Next()
+	}
+}
+
+// Identity 认证通过的凭证信息
+type Identity struct {
+	UserID   uint
+	Username string
+	TokenID  uint     // 个人访问令牌ID，登录会话为0
+	Scopes   []string // 个人访问令牌的权限范围，登录会话为nil，拥有全部权限
+}
+
+// authenticate 校验JWT或个人访问令牌，成功时将用户信息存储到上下文
+func authenticate(c *gin.Context, token string) (int, string) {
+	identity, status, message := Authenticate(token)
+	if status != http.StatusOK {
+		return status, message
+	}
+
+	c.Set("userID", identity.UserID)
+	c.Set("username", identity.Username)
+	// 登录会话不限制权限范围
+	if identity.TokenID != 0 {
+		c.Set("tokenID", identity.TokenID)
+		c.Set("scopes", identity.Scopes)
+	}
+	return http.StatusOK, ""
+}
+
+// Authenticate 校验JWT或个人访问令牌，返回凭证信息，失败时返回HTTP状态码和原因
+// 供gin以外的入口（如gRPC拦截器）使用与REST接口相同的认证规则
+func Authenticate(token string) (*Identity, int, string) {
+	// 个人访问令牌
+	if utils.IsAccessToken(token) {
+		return authenticateAccessToken(token)
+	}
+
+	// 解析JWT令牌
+	claims, err := utils.ParseToken(token)
+	if err != nil {
+		return nil, http.StatusUnauthorized, "无效的认证令牌"
+	}
+
+	// 检查用户状态：已删除、被停用或被强制下线的用户令牌失效
+	var user models.User
+	if err := config.DB.Select("id", "suspended_at", "sessions_revoked_at").First(&user, claims.UserID).Error; err != nil {
+		return nil, http.StatusUnauthorized, "用户不存在"
+	}
+	if status, message := checkUserStatus(&user); status != http.StatusOK {
+		return nil, status, message
+	}
+	if claims.IssuedAt == nil || user.SessionRevoked(claims.IssuedAt.Time) {
+		return nil, http.StatusUnauthorized, "登录已失效，请重新登录"
+	}
+
+	return &Identity{UserID: claims.UserID, Username: claims.Username}, http.StatusOK, ""
+}
+
+// authenticateAccessToken 校验个人访问令牌，返回用户信息和权限范围
+func authenticateAccessToken(token string) (*Identity, int, string) {
+	var pat models.PersonalAccessToken
+	if err := config.DB.Preload("User").Where("token_hash = ?", utils.HashAccessToken(token)).First(&pat).Error; err != nil {
+		return nil, http.StatusUnauthorized, "无效的访问令牌"
+	}
+
+	now := time.Now()
+	if pat.Expired(now) {
+		return nil, http.StatusUnauthorized, "访问令牌已过期"
+	}
+	// 令牌所属的用户已删除时预加载结果为空
+	if pat.User.ID == 0 {
+		return nil, http.StatusUnauthorized, "用户不存在"
+	}
+	if status, message := checkUserStatus(&pat.User); status != http.StatusOK {
+		return nil, status, message
+	}
+
+	// 更新最后使用时间
+	if pat.LastUsedAt == nil || now.Sub(*pat.LastUsedAt) > tokenTouchInterval {
+		config.DB.Model(&pat).UpdateColumn("last_used_at", now)
+	}
+
+	return &Identity{
+		UserID:   pat.UserID,
+		Username: pat.User.Username,
+		TokenID:  pat.ID,
+		Scopes:   pat.ScopeList(),
+	}, http.StatusOK, ""
+}
+
+// checkUserStatus 拒绝被停用的用户
+func checkUserStatus(user *models.User) (int, string) {
+	if user.IsSuspended() {
+		return http.StatusForbidden, "账号已被停用"
+	}
+	return http.StatusOK, ""
+}
+
+// RequireAdmin 要求当前用户为管理员，角色以数据库为准，需放在AuthMiddleware之后
+func RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var user models.User
+		if err := config.DB.Select("id", "role").First(&user, c.GetUint("userID")).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				c.JSON(http.StatusUnauthorized, models.Response{
+					Code:    http.StatusUnauthorized,
+					Message: "用户不存在",
+				})
+			} else {
+				c.JSON(http.StatusInternalServerError, models.Response{
+					Code:    http.StatusInternalServerError,
+					Message: "获取用户信息失败: " + err.Error(),
+				})
+			}
+			c.Abort()
+			return
+		}
+		if !user.IsAdmin() {
+			c.JSON(http.StatusForbidden, models.Response{
+				Code:    http.StatusForbidden,
+				Message: "需要管理员权限",
 			})
 			c.Abort()
 			return
 		}
+		c.Next()
+	}
+}
+
+// RequireScope 要求当前凭证拥有指定的权限范围
+// 登录会话（JWT）拥有全部权限，个人访问令牌只拥有创建时选择的权限
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, limited := c.Get("scopes")
+		if !limited {
+			c.Next()
+			return
+		}
+
+		for _, s := range value.([]string) {
+			if s == scope {
+				c.Next()
+				return
+			}
+		}
 
-		// 将用户信息存储到上下文
-		c.Set("userID", claims.UserID)
-		c.Set("username", claims.Username)
+		c.JSON(http.StatusForbidden, models.Response{
+			Code:    http.StatusForbidden,
+			Message: "访问令牌缺少权限: " + scope,
+		})
+		c.Abort()
+	}
+}
+
+// QueryTokenMiddleware 允许通过access_token查询参数传递令牌
+// 浏览器的EventSource无法设置请求头，只用于实时推送等长连接路由，需放在AuthMiddleware之前
+func QueryTokenMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token := c.Query("access_token"); token != "" && c.GetHeader("Authorization") == "" {
+			c.Request.Header.Set("Authorization", "Bearer "+token)
+		}
 		c.Next()
 	}
-} 
\ No newline at end of file
+}
@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"log"
+	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -25,8 +26,8 @@ func LoggerMiddleware() gin.HandlerFunc {
 		// 请求方式
 		reqMethod := c.Request.Method
 
-		// 请求路由
-		reqUri := c.Request.RequestURI
+		// 请求路由，隐去查询参数中的令牌
+		reqUri := redactURI(c.Request)
 
 		// 状态码
 		statusCode := c.Writer.Status()
@@ -34,13 +35,38 @@ func LoggerMiddleware() gin.HandlerFunc {
 		// 请求IP
 		clientIP := c.ClientIP()
 
+		// 请求ID
+		requestID := c.GetString("requestID")
+
 		// 日志格式
-		log.Printf("| %3d | %13v | %15s | %s | %s |",
+		log.Printf("| %3d | %13v | %15s | %s | %s | %s |",
 			statusCode,
 			latencyTime,
 			clientIP,
+			requestID,
 			reqMethod,
 			reqUri,
 		)
 	}
-} 
\ No newline at end of file
+}
+
+// redactedParams 日志中需要隐去的查询参数，实时推送通过access_token传递令牌
+var redactedParams = []string{"access_token"}
+
+// redactURI 返回用于记录日志的请求URI，令牌参数的值替换为REDACTED
+func redactURI(r *http.Request) string {
+	query := r.URL.Query()
+	redacted := false
+	for _, name := range redactedParams {
+		if query.Has(name) {
+			query.Set(name, "REDACTED")
+			redacted = true
+		}
+	}
+	if !redacted {
+		return r.RequestURI
+	}
+	u := *r.URL
+	u.RawQuery = query.Encode()
+	return u.RequestURI()
+}
@@ -0,0 +1,210 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 删除用户时对其文章和评论的处理方式
+const (
+	UserContentReassign = "reassign" // 转给其他用户
+	UserContentDelete   = "delete"   // 文章移入回收站，评论删除
+)
+
+// UserContentChanges 删除用户时被转移或删除的文章和评论
+type UserContentChanges struct {
+	Posts        []uint
+	Comments     []uint
+	CommentPosts []uint // 评论所在的文章，评论列表和评论数量随之变化
+}
+
+// ReassignUserContent 将用户的文章（包括回收站中的）、评论和附件转给另一个用户，并调整双方的文章数量
+func ReassignUserContent(tx *gorm.DB, fromID, toID uint) (UserContentChanges, error) {
+	var changes UserContentChanges
+	if err := tx.Unscoped().Model(&Post{}).Where("user_id = ?", fromID).Pluck("id", &changes.Posts).Error; err != nil {
+		return changes, err
+	}
+	if err := tx.Unscoped().Model(&Comment{}).Where("user_id = ?", fromID).Pluck("id", &changes.Comments).Error; err != nil {
+		return changes, err
+	}
+	if err := tx.Unscoped().Model(&Comment{}).Where("user_id = ?", fromID).Distinct().Pluck("post_id", &changes.CommentPosts).Error; err != nil {
+		return changes, err
+	}
+
+	var live int64
+	if err := tx.Model(&Post{}).Where("user_id = ?", fromID).Count(&live).Error; err != nil {
+		return changes, err
+	}
+	for _, model := range []interface{}{&Post{}, &Comment{}, &Attachment{}} {
+		if err := tx.Unscoped().Model(model).Where("user_id = ?", fromID).UpdateColumn("user_id", toID).Error; err != nil {
+			return changes, err
+		}
+	}
+
+	if err := tx.Model(&User{}).Where("id = ?", fromID).UpdateColumn("post_count", 0).Error; err != nil {
+		return changes, err
+	}
+	return changes, tx.Model(&User{}).Where("id = ?", toID).
+		UpdateColumn("post_count", gorm.Expr("post_count + ?", live)).Error
+}
+
+// RemoveUserContent 将用户的文章连同评论和附件移入回收站，并删除用户在其他文章下的评论
+// 回收站中的内容超过保留期限后被彻底删除
+func RemoveUserContent(tx *gorm.DB, userID uint) (UserContentChanges, error) {
+	var changes UserContentChanges
+
+	var posts []Post
+	if err := tx.Where("user_id = ?", userID).Find(&posts).Error; err != nil {
+		return changes, err
+	}
+	for i := range posts {
+		if err := TrashPost(tx, &posts[i]); err != nil {
+			return changes, err
+		}
+		changes.Posts = append(changes.Posts, posts[i].ID)
+	}
+
+	// 自己文章下的评论已随文章移入回收站，剩下的是其他文章下的评论
+	var comments []Comment
+	if err := tx.Select("id", "post_id", "status").Where("user_id = ?", userID).Find(&comments).Error; err != nil {
+		return changes, err
+	}
+	if len(comments) == 0 {
+		return changes, nil
+	}
+	published := make(map[uint]int)
+	for _, comment := range comments {
+		changes.Comments = append(changes.Comments, comment.ID)
+		if _, ok := published[comment.PostID]; !ok {
+			published[comment.PostID] = 0
+			changes.CommentPosts = append(changes.CommentPosts, comment.PostID)
+		}
+		if comment.Status == CommentApproved {
+			published[comment.PostID]++
+		}
+	}
+	if err := tx.Model(&Comment{}).Where("id IN ?", changes.Comments).
+		UpdateColumn("deleted_at", time.Now()).Error; err != nil {
+		return changes, err
+	}
+	for postID, n := range published {
+		if n == 0 {
+			continue
+		}
+		if err := adjustCommentCount(tx, postID, -n); err != nil {
+			return changes, err
+		}
+	}
+	return changes, nil
+}
+
+// DeleteUser 删除用户及其个人数据：访问令牌、关注关系、收藏、表态和通知
+// 用户记录软删除，用户名和邮箱不能再被注册；用户提交的举报保留作为记录
+func DeleteUser(tx *gorm.DB, user *User) error {
+	id := user.ID
+	relations := []struct {
+		model interface{}
+		query string
+		args  []interface{}
+	}{
+		{&PersonalAccessToken{}, "user_id = ?", []interface{}{id}},
+		{&Follow{}, "follower_id = ? OR followee_id = ?", []interface{}{id, id}},
+		{&Bookmark{}, "user_id = ?", []interface{}{id}},
+		{&BookmarkCollection{}, "user_id = ?", []interface{}{id}},
+		{&Reaction{}, "user_id = ?", []interface{}{id}},
+		{&Notification{}, "recipient_id = ? OR actor_id = ?", []interface{}{id, id}},
+	}
+	for _, r := range relations {
+		if err := tx.Unscoped().Where(r.query, r.args...).Delete(r.model).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Delete(user).Error
+}
+
+// ManagedUser 管理员接口返回的用户信息，包括不对外公开的停用时间和原因
+type ManagedUser struct {
+	User
+	SuspendedAt   *time.Time `json:"suspended_at,omitempty"`
+	SuspendReason string     `json:"suspend_reason,omitempty"`
+}
+
+// NewManagedUser 生成管理员接口返回的用户信息
+func NewManagedUser(user *User) ManagedUser {
+	return ManagedUser{User: *user, SuspendedAt: user.SuspendedAt, SuspendReason: user.SuspendReason}
+}
+
+// SuspendInput 停用用户的输入
+type SuspendInput struct {
+	Reason string `json:"reason" binding:"max=255"`
+}
+
+// DailyCount 某一天新增的数量
+type DailyCount struct {
+	Date     string `json:"date"`
+	Users    int64  `json:"users"`
+	Posts    int64  `json:"posts"`
+	Comments int64  `json:"comments"`
+}
+
+// SiteStats 站点统计
+type SiteStats struct {
+	Users    int64        `json:"users"`
+	Posts    int64        `json:"posts"`
+	Comments int64        `json:"comments"`
+	Daily    []DailyCount `json:"daily"` // 最早的一天在前，没有新增的日期数量为0
+}
+
+// LoadSiteStats 统计当前的用户、文章和评论总数，以及最近days天每天新增的数量
+// 每天新增的数量包括之后被删除的记录
+func LoadSiteStats(db *gorm.DB, days int) (SiteStats, error) {
+	var stats SiteStats
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).AddDate(0, 0, 1-days)
+
+	daily := make(map[string]*DailyCount, days)
+	for i := 0; i < days; i++ {
+		date := start.AddDate(0, 0, i).Format("2006-01-02")
+		stats.Daily = append(stats.Daily, DailyCount{Date: date})
+	}
+	for i := range stats.Daily {
+		daily[stats.Daily[i].Date] = &stats.Daily[i]
+	}
+
+	tables := []struct {
+		model interface{}
+		total *int64
+		count func(*DailyCount) *int64
+	}{
+		{&User{}, &stats.Users, func(d *DailyCount) *int64 { return &d.Users }},
+		{&Post{}, &stats.Posts, func(d *DailyCount) *int64 { return &d.Posts }},
+		{&Comment{}, &stats.Comments, func(d *DailyCount) *int64 { return &d.Comments }},
+	}
+	for _, t := range tables {
+		if err := db.Model(t.model).Count(t.total).Error; err != nil {
+			return stats, err
+		}
+
+		var rows []struct {
+			Day   string
+			Count int64
+		}
+		if err := db.Unscoped().Model(t.model).
+			Select("DATE(created_at) AS day, COUNT(*) AS count").
+			Where("created_at >= ?", start).
+			Group("DATE(created_at)").Scan(&rows).Error; err != nil {
+			return stats, err
+		}
+		for _, row := range rows {
+			// MySQL返回的日期可能带有时间部分
+			if len(row.Day) > 10 {
+				row.Day = row.Day[:10]
+			}
+			if d, ok := daily[row.Day]; ok {
+				*t.count(d) = row.Count
+			}
+		}
+	}
+	return stats, nil
+}
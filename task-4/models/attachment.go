@@ -0,0 +1,50 @@
+package models
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// Attachment 文章附件模型
+type Attachment struct {
+	gorm.Model
+	PostID        uint   `gorm:"index;not null" json:"post_id"`
+	UserID        uint   `gorm:"index;not null" json:"user_id"`
+	FileName      string `gorm:"type:varchar(255);not null" json:"file_name"`
+	ContentType   string `gorm:"type:varchar(100);not null" json:"content_type"`
+	Size          int64  `gorm:"not null" json:"size"`
+	SHA256        string `gorm:"type:char(64);not null" json:"sha256"`
+	StorageKey    string `gorm:"type:varchar(255);not null" json:"-"`
+	ThumbnailKey  string `gorm:"type:varchar(255)" json:"-"`
+	ThumbnailType string `gorm:"type:varchar(100)" json:"-"`
+	Width         int    `json:"width,omitempty"`
+	Height        int    `json:"height,omitempty"`
+	URL           string `gorm:"-" json:"url"`
+	ThumbnailURL  string `gorm:"-" json:"thumbnail_url,omitempty"`
+}
+
+// IsImage 判断附件是否为图片
+func (a *Attachment) IsImage() bool {
+	return a.ThumbnailKey != ""
+}
+
+// AfterFind 查询后填充访问地址
+func (a *Attachment) AfterFind(tx *gorm.DB) (err error) {
+	a.fillURLs()
+	return
+}
+
+// AfterCreate 创建后填充访问地址
+func (a *Attachment) AfterCreate(tx *gorm.DB) (err error) {
+	a.fillURLs()
+	return
+}
+
+// fillURLs 填充附件和缩略图的访问地址
+func (a *Attachment) fillURLs() {
+	a.URL = fmt.Sprintf("/api/attachments/%d", a.ID)
+	if a.IsImage() {
+		a.ThumbnailURL = fmt.Sprintf("/api/attachments/%d/thumbnail", a.ID)
+	}
+}
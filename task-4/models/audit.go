@@ -0,0 +1,164 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 审计操作
+const (
+	AuditCreate  = "create"
+	AuditUpdate  = "update"
+	AuditDelete  = "delete"  // 移入回收站
+	AuditRestore = "restore" // 从回收站恢复
+	AuditPurge   = "purge"   // 彻底删除
+)
+
+// TargetUser 审计日志中的用户对象，文章和评论沿用表态的对象类型
+const TargetUser = "user"
+
+// AuditSystem 定时任务、命令行操作和自动处理的执行者名称
+const AuditSystem = "system"
+
+// ErrAuditImmutable 审计日志只能追加，不能修改或删除
+var ErrAuditImmutable = errors.New("审计日志不能修改或删除")
+
+// 不记录在变更中的字段：由数据库维护的时间戳，以及由正文生成的HTML
+var auditIgnoredFields = map[string]bool{
+	"ID":           true,
+	"CreatedAt":    true,
+	"UpdatedAt":    true,
+	"DeletedAt":    true,
+	"content_html": true,
+}
+
+// AuditChange 字段修改前后的值，创建时没有修改前的值，删除时没有修改后的值
+type AuditChange struct {
+	Before interface{} `json:"before,omitempty"`
+	After  interface{} `json:"after,omitempty"`
+}
+
+// AuditEvent 审计日志，记录用户、文章和评论的每次创建、修改和删除
+type AuditEvent struct {
+	ID         uint                   `gorm:"primarykey" json:"id"`
+	CreatedAt  time.Time              `gorm:"index" json:"created_at"`
+	ActorID    *uint                  `gorm:"index" json:"actor_id"` // 定时任务和命令行操作为空
+	ActorName  string                 `gorm:"type:varchar(100);not null" json:"actor_name"`
+	TokenID    *uint                  `json:"token_id,omitempty"` // 通过个人访问令牌操作时的令牌
+	Action     string                 `gorm:"type:varchar(20);not null;index" json:"action"`
+	TargetType string                 `gorm:"type:varchar(20);not null;index:idx_audit_target,priority:1" json:"target_type"`
+	TargetID   uint                   `gorm:"not null;index:idx_audit_target,priority:2" json:"target_id"`
+	Changes    map[string]AuditChange `gorm:"type:text;serializer:json" json:"changes"`
+	IP         string                 `gorm:"type:varchar(64)" json:"ip"`
+	RequestID  string                 `gorm:"type:varchar(64);index" json:"request_id"`
+}
+
+// BeforeUpdate 禁止修改审计日志
+func (e *AuditEvent) BeforeUpdate(tx *gorm.DB) (err error) {
+	return ErrAuditImmutable
+}
+
+// BeforeDelete 禁止删除审计日志
+func (e *AuditEvent) BeforeDelete(tx *gorm.DB) (err error) {
+	return ErrAuditImmutable
+}
+
+// AuditDiff 比较对象修改前后的JSON字段，返回发生变化的字段
+// before为nil表示创建，after为nil表示删除；空值视为没有该字段，关联对象和列表不参与比较，
+// 不输出JSON的字段（如密码）不会被记录
+func AuditDiff(before, after interface{}) map[string]AuditChange {
+	old := auditFields(before)
+	cur := auditFields(after)
+
+	changes := make(map[string]AuditChange)
+	for key, value := range old {
+		if next, ok := cur[key]; !ok || !reflect.DeepEqual(value, next) {
+			changes[key] = AuditChange{Before: value, After: next}
+		}
+	}
+	for key, value := range cur {
+		if _, ok := old[key]; !ok {
+			changes[key] = AuditChange{After: value}
+		}
+	}
+	return changes
+}
+
+// auditFields 将对象转换为字段名到值的映射，去掉忽略的字段、关联对象和空值
+func auditFields(v interface{}) map[string]interface{} {
+	fields := make(map[string]interface{})
+	if v == nil {
+		return fields
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return fields
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fields
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fields
+	}
+	for key, value := range fields {
+		switch value.(type) {
+		case nil, map[string]interface{}, []interface{}:
+			delete(fields, key)
+			continue
+		}
+		if auditIgnoredFields[key] {
+			delete(fields, key)
+		}
+	}
+	return fields
+}
+
+// AuditQuery 审计日志查询条件
+type AuditQuery struct {
+	ActorID    string `form:"actor_id"`
+	Action     string `form:"action"`
+	TargetType string `form:"target_type"`
+	TargetID   string `form:"target_id"`
+	RequestID  string `form:"request_id"`
+	Since      string `form:"since"` // RFC3339格式的时间
+	Until      string `form:"until"`
+}
+
+// Apply 将查询条件应用到查询上，时间格式错误时返回错误
+func (q *AuditQuery) Apply(db *gorm.DB) (*gorm.DB, error) {
+	if q.ActorID != "" {
+		db = db.Where("actor_id = ?", q.ActorID)
+	}
+	if q.Action != "" {
+		db = db.Where("action = ?", q.Action)
+	}
+	if q.TargetType != "" {
+		db = db.Where("target_type = ?", q.TargetType)
+	}
+	if q.TargetID != "" {
+		db = db.Where("target_id = ?", q.TargetID)
+	}
+	if q.RequestID != "" {
+		db = db.Where("request_id = ?", q.RequestID)
+	}
+	if q.Since != "" {
+		since, err := time.Parse(time.RFC3339, q.Since)
+		if err != nil {
+			return nil, errors.New("since格式错误，应为RFC3339时间")
+		}
+		db = db.Where("created_at >= ?", since)
+	}
+	if q.Until != "" {
+		until, err := time.Parse(time.RFC3339, q.Until)
+		if err != nil {
+			return nil, errors.New("until格式错误，应为RFC3339时间")
+		}
+		db = db.Where("created_at < ?", until)
+	}
+	return db, nil
+}
@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// BookmarkCollection 收藏夹，同一用户的收藏夹名称不能重复
+type BookmarkCollection struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	UserID    uint      `gorm:"not null;uniqueIndex:idx_collection_user_name,priority:1" json:"-"`
+	Name      string    `gorm:"type:varchar(100);not null;uniqueIndex:idx_collection_user_name,priority:2" json:"name"`
+}
+
+// Bookmark 收藏，只有收藏者本人可见
+type Bookmark struct {
+	ID           uint                `gorm:"primarykey" json:"id"`
+	CreatedAt    time.Time           `json:"created_at"`
+	UpdatedAt    time.Time           `json:"updated_at"`
+	UserID       uint                `gorm:"not null;uniqueIndex:idx_bookmark_user_post,priority:1" json:"-"`
+	PostID       uint                `gorm:"not null;uniqueIndex:idx_bookmark_user_post,priority:2;index" json:"post_id"`
+	Post         Post                `json:"-"`
+	CollectionID *uint               `gorm:"index" json:"collection_id"`
+	Collection   *BookmarkCollection `json:"-"`
+}
+
+// BookmarkInput 收藏输入，collection_id为空时收藏到默认列表
+type BookmarkInput struct {
+	CollectionID *uint `json:"collection_id"`
+}
+
+// CollectionInput 收藏夹输入
+type CollectionInput struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+// BookmarkResponse 收藏列表项
+// 文章被删除后收藏不会消失，available为false且不再返回文章内容
+type BookmarkResponse struct {
+	ID           uint      `json:"id"`
+	PostID       uint      `json:"post_id"`
+	CollectionID *uint     `json:"collection_id"`
+	CreatedAt    time.Time `json:"created_at"`
+	Available    bool      `json:"available"`
+	Notice       string    `json:"notice,omitempty"`
+	Post         *Post     `json:"post,omitempty"`
+}
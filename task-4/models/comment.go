@@ -1,20 +1,150 @@
 package models
 
 import (
+	"time"
+
+	"github.com/xhy/blog-api/markdown"
 	"gorm.io/gorm"
 )
 
+// 评论审核状态
+const (
+	CommentPending  = "pending"  // 等待审核，只有评论者本人可见
+	CommentApproved = "approved" // 已发布
+	CommentRejected = "rejected"
+	CommentSpam     = "spam"
+)
+
 // Comment 评论模型
 type Comment struct {
 	gorm.Model
-	Content string `gorm:"type:text;not null" json:"content"`
-	UserID  uint   `json:"user_id"`
-	User    User   `json:"user,omitempty"`
-	PostID  uint   `json:"post_id"`
-	Post    Post   `json:"post,omitempty" gorm:"foreignKey:PostID"`
+	Content      string            `gorm:"type:text;not null" json:"content"`
+	ContentHTML  string            `gorm:"type:text" json:"content_html"`
+	UserID       uint              `json:"user_id"`
+	User         User              `json:"user,omitempty"`
+	PostID       uint              `gorm:"index:idx_comment_post_status,priority:1" json:"post_id"`
+	ParentID     *uint             `gorm:"index" json:"parent_id"`
+	Status       string            `gorm:"type:varchar(20);not null;default:approved;index:idx_comment_post_status,priority:2" json:"status"`
+	ModeratedBy  *uint             `json:"moderated_by,omitempty"`
+	ModeratedAt  *time.Time        `json:"moderated_at,omitempty"`
+	FilterReason string            `gorm:"type:varchar(255)" json:"filter_reason,omitempty"` // 被内容过滤器转入审核的原因
+	TrainedAs    string            `gorm:"type:varchar(20);not null;default:''" json:"-"`    // 训练垃圾内容分类器时计入的样本类别，未参与训练时为空
+	Post         Post              `json:"post,omitempty" gorm:"foreignKey:PostID"`
+	Reactions    []ReactionSummary `gorm:"-" json:"reactions"`
+}
+
+// ReportHidden 判断评论是否因举报达到阈值被自动隐藏
+// 自动隐藏的评论处于等待审核状态且没有处理人，只能由版主处理举报后恢复或拒绝
+func (c *Comment) ReportHidden() bool {
+	return c.Status == CommentPending && c.ModeratedAt != nil && c.ModeratedBy == nil
+}
+
+// ExcludeReportHidden 排除被举报自动隐藏的评论，用于文章作者的审核队列
+func ExcludeReportHidden(db *gorm.DB) *gorm.DB {
+	return db.Where("NOT (comments.status = ? AND comments.moderated_at IS NOT NULL AND comments.moderated_by IS NULL)", CommentPending)
+}
+
+// Render 使用受限的Markdown子集渲染评论
+func (c *Comment) Render() {
+	c.ContentHTML = markdown.RenderComment(c.Content)
+}
+
+// BeforeSave 保存前渲染评论
+func (c *Comment) BeforeSave(tx *gorm.DB) (err error) {
+	if c.Content == "" {
+		return
+	}
+	c.Render()
+	return
+}
+
+// BeforeCreate 未指定审核状态的评论直接发布
+func (c *Comment) BeforeCreate(tx *gorm.DB) (err error) {
+	if c.Status == "" {
+		c.Status = CommentApproved
+	}
+	return
+}
+
+// AfterCreate 在创建评论的同一事务中增加文章的评论数量，等待审核的评论不计入
+// 只更新计数列，不改变文章的修改时间
+func (c *Comment) AfterCreate(tx *gorm.DB) (err error) {
+	if c.Status != CommentApproved {
+		return
+	}
+	return adjustCommentCount(tx, c.PostID, 1)
+}
+
+// AfterDelete 在删除评论的同一事务中减少文章的评论数量
+func (c *Comment) AfterDelete(tx *gorm.DB) (err error) {
+	if c.ID == 0 || c.PostID == 0 || c.Status != CommentApproved {
+		return
+	}
+	return adjustCommentCount(tx, c.PostID, -1)
+}
+
+// Moderate 修改评论的审核状态，发布或撤下评论时在同一事务中调整文章的评论数量
+// moderatorID为0表示系统自动处理；评论已被其他请求修改为其他状态时不做修改，返回false
+func (c *Comment) Moderate(tx *gorm.DB, status string, moderatorID uint) (bool, error) {
+	if c.Status == status {
+		return false, nil
+	}
+
+	var moderatedBy *uint
+	if moderatorID != 0 {
+		moderatedBy = &moderatorID
+	}
+	now := time.Now()
+	result := tx.Model(&Comment{}).Where("id = ? AND status = ?", c.ID, c.Status).
+		UpdateColumns(map[string]interface{}{"status": status, "moderated_by": moderatedBy, "moderated_at": now})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false, result.Error
+	}
+
+	delta := 0
+	if status == CommentApproved {
+		delta = 1
+	} else if c.Status == CommentApproved {
+		delta = -1
+	}
+	c.Status = status
+	c.ModeratedBy = moderatedBy
+	c.ModeratedAt = &now
+
+	if delta == 0 {
+		return true, nil
+	}
+	return true, adjustCommentCount(tx, c.PostID, delta)
+}
+
+// adjustCommentCount 增减文章的评论数量
+func adjustCommentCount(tx *gorm.DB, postID uint, delta int) error {
+	query := tx.Model(&Post{}).Where("id = ?", postID)
+	if delta < 0 {
+		query = query.Where("comment_count > 0")
+	}
+	return query.UpdateColumn("comment_count", gorm.Expr("comment_count + ?", delta)).Error
+}
+
+// IsCommentStatus 判断是否为支持的评论审核状态
+func IsCommentStatus(status string) bool {
+	return status == CommentPending || status == CommentApproved || status == CommentRejected || status == CommentSpam
+}
+
+// ModerationInput 批量审核评论的输入
+type ModerationInput struct {
+	IDs    []uint `json:"ids" binding:"required,min=1,max=100"`
+	Action string `json:"action" binding:"required,oneof=approve reject spam"`
+}
+
+// ModerationResult 批量审核的结果，skipped为不存在、没有权限或状态未变化的评论
+type ModerationResult struct {
+	Updated []uint `json:"updated"`
+	Skipped []uint `json:"skipped"`
 }
 
 // CommentInput 评论输入
 type CommentInput struct {
-	Content string `json:"content" binding:"required"`
+	Content  string `json:"content" binding:"required"`
+	ParentID *uint  `json:"parent_id"` // 回复的评论ID，必须属于同一篇文章
 }
@@ -0,0 +1,92 @@
+package models
+
+import "gorm.io/gorm"
+
+// CounterDrift 计数列与实际数量不一致的记录
+type CounterDrift struct {
+	Table  string `json:"table"`
+	ID     uint   `json:"id"`
+	Column string `json:"column"`
+	Stored int64  `json:"stored"`
+	Actual int64  `json:"actual"`
+}
+
+// counterSpec 一个计数列及其对应的聚合来源
+type counterSpec struct {
+	table       string // 计数列所在的表
+	column      string
+	childTable  string // 被计数的表
+	foreignKey  string
+	softDeleted bool   // 被计数的表是否使用软删除
+	condition   string // 被计数的记录需要满足的额外条件
+}
+
+var counterSpecs = []counterSpec{
+	{table: "users", column: "post_count", childTable: "posts", foreignKey: "user_id", softDeleted: true},
+	{table: "posts", column: "comment_count", childTable: "comments", foreignKey: "post_id", softDeleted: true, condition: "c.status = 'approved'"},
+}
+
+// ReconcileCounters 用聚合SQL重新统计所有计数列，返回发现的偏差
+// fix为true时把偏差的记录更新为实际数量，否则只报告
+func ReconcileCounters(db *gorm.DB, fix bool) ([]CounterDrift, error) {
+	var drifts []CounterDrift
+
+	for _, spec := range counterSpecs {
+		join := "LEFT JOIN " + spec.childTable + " c ON c." + spec.foreignKey + " = t.id"
+		if spec.softDeleted {
+			join += " AND c.deleted_at IS NULL"
+		}
+		if spec.condition != "" {
+			join += " AND " + spec.condition
+		}
+
+		var rows []struct {
+			ID     uint
+			Stored int64
+			Actual int64
+		}
+		if err := db.Table(spec.table + " t").
+			Select("t.id, t." + spec.column + " AS stored, COUNT(c.id) AS actual").
+			Joins(join).
+			Where("t.deleted_at IS NULL").
+			Group("t.id, t." + spec.column).
+			Having("t." + spec.column + " <> COUNT(c.id)").
+			Scan(&rows).Error; err != nil {
+			return nil, err
+		}
+		if len(rows) == 0 {
+			continue
+		}
+
+		ids := make([]uint, len(rows))
+		for i, row := range rows {
+			ids[i] = row.ID
+			drifts = append(drifts, CounterDrift{
+				Table:  spec.table,
+				ID:     row.ID,
+				Column: spec.column,
+				Stored: row.Stored,
+				Actual: row.Actual,
+			})
+		}
+
+		if !fix {
+			continue
+		}
+
+		// 重新计算而不是写入上面查到的数量，避免覆盖统计期间发生的变化
+		count := "SELECT COUNT(*) FROM " + spec.childTable + " c WHERE c." + spec.foreignKey + " = " + spec.table + ".id"
+		if spec.softDeleted {
+			count += " AND c.deleted_at IS NULL"
+		}
+		if spec.condition != "" {
+			count += " AND " + spec.condition
+		}
+		if err := db.Table(spec.table).Where("id IN ?", ids).
+			UpdateColumn(spec.column, gorm.Expr("("+count+")")).Error; err != nil {
+			return drifts, err
+		}
+	}
+
+	return drifts, nil
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// Follow 关注关系，follower关注followee
+// 唯一索引(follower_id, followee_id)同时用于生成关注动态时判断文章作者是否被关注
+type Follow struct {
+	ID         uint      `gorm:"primarykey" json:"-"`
+	CreatedAt  time.Time `json:"created_at"`
+	FollowerID uint      `gorm:"not null;uniqueIndex:idx_follow_pair,priority:1" json:"follower_id"`
+	FolloweeID uint      `gorm:"not null;uniqueIndex:idx_follow_pair,priority:2;index" json:"followee_id"`
+}
+
+// UserBrief 用户简要信息
+type UserBrief struct {
+	ID       uint   `json:"id"`
+	Username string `json:"username"`
+}
+
+// UserProfile 用户主页信息
+type UserProfile struct {
+	ID             uint      `json:"id"`
+	Username       string    `json:"username"`
+	CreatedAt      time.Time `json:"created_at"`
+	PostCount      int64     `json:"post_count"`
+	FollowerCount  int64     `json:"follower_count"`
+	FollowingCount int64     `json:"following_count"`
+	Following      bool      `json:"following"` // 当前用户是否已关注，未登录时为false
+}
+
+// FeedResponse 关注动态，next_cursor为空表示没有更多数据
+type FeedResponse struct {
+	Posts      []Post `json:"posts"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
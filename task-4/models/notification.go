@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// 通知类型
+const (
+	NotificationComment  = "comment"  // 有人评论了我的文章
+	NotificationReply    = "reply"    // 有人回复了我的评论
+	NotificationReaction = "reaction" // 有人对我的文章或评论表态
+)
+
+// Notification 站内通知
+type Notification struct {
+	ID          uint       `gorm:"primarykey" json:"id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	RecipientID uint       `gorm:"not null;index:idx_notification_recipient,priority:1" json:"recipient_id"`
+	ReadAt      *time.Time `gorm:"index:idx_notification_recipient,priority:2" json:"read_at"`
+	ActorID     uint       `gorm:"not null" json:"actor_id"`
+	Actor       User       `gorm:"foreignKey:ActorID" json:"actor"`
+	Type        string     `gorm:"type:varchar(20);not null" json:"type"`
+	PostID      uint       `gorm:"not null" json:"post_id"`
+	CommentID   *uint      `json:"comment_id,omitempty"`
+	Emoji       string     `gorm:"type:varchar(20)" json:"emoji,omitempty"`
+}
+
+// NotificationListResponse 通知列表
+type NotificationListResponse struct {
+	Notifications []Notification `json:"notifications"`
+	UnreadCount   int64          `json:"unread_count"`
+}
@@ -1,21 +1,101 @@
 package models
 
 import (
+	"github.com/xhy/blog-api/markdown"
 	"gorm.io/gorm"
 )
 
+// 文章内容格式
+const (
+	FormatMarkdown = "markdown"
+	FormatPlain    = "plain"
+)
+
+// 评论审核策略
+const (
+	ModerationAuto    = "auto"    // 评论直接发布
+	ModerationTrusted = "trusted" // 受信任用户的评论直接发布，其他评论需要审核
+	ModerationAll     = "all"     // 所有评论都需要审核
+)
+
+// IsModerationPolicy 判断是否为支持的评论审核策略
+func IsModerationPolicy(policy string) bool {
+	return policy == ModerationAuto || policy == ModerationTrusted || policy == ModerationAll
+}
+
 // Post 文章模型
 type Post struct {
 	gorm.Model
-	Title    string    `gorm:"type:varchar(200);not null" json:"title"`
-	Content  string    `gorm:"type:text;not null" json:"content"`
-	UserID   uint      `json:"user_id"`
-	User     User      `json:"user,omitempty"`
-	Comments []Comment `json:"comments,omitempty"`
+	Title        string             `gorm:"type:varchar(200);not null" json:"title"`
+	Slug         string             `gorm:"type:varchar(200);not null;default:'';index" json:"slug"`
+	SlugCustom   bool               `gorm:"not null;default:false" json:"-"` // 别名由作者指定，修改标题时不再重新生成
+	Content      string             `gorm:"type:text;not null" json:"content"`
+	Format       string             `gorm:"type:varchar(20);not null;default:markdown" json:"format"`
+	ContentHTML  string             `gorm:"type:mediumtext" json:"content_html,omitempty"`
+	TOC          []markdown.Heading `gorm:"type:text;serializer:json" json:"toc,omitempty"`
+	UserID       uint               `gorm:"index" json:"user_id"`
+	CommentCount int64              `gorm:"not null;default:0" json:"comment_count"`                // 已发布的评论数量，随评论创建、删除和审核更新
+	ViewCount    int64              `gorm:"not null;default:0" json:"view_count"`                   // 阅读量，由计数器定期批量写入
+	Moderation   string             `gorm:"type:varchar(20);not null;default:''" json:"moderation"` // 评论审核策略，为空时使用全站策略
+	Hidden       bool               `gorm:"not null;default:false;index" json:"hidden,omitempty"`   // 被举报隐藏，只有作者可见
+	User         User               `json:"user,omitempty"`
+	Comments     []Comment          `json:"comments,omitempty"`
+	Reactions    []ReactionSummary  `gorm:"-" json:"reactions"`
+}
+
+// VisiblePosts 只查询没有被举报隐藏的文章
+func VisiblePosts(db *gorm.DB) *gorm.DB {
+	return db.Where("posts.hidden = ?", false)
+}
+
+// Render 根据内容格式渲染HTML和目录
+func (p *Post) Render() {
+	if p.Format == FormatPlain {
+		p.ContentHTML = markdown.RenderPlain(p.Content)
+		p.TOC = nil
+		return
+	}
+	p.Format = FormatMarkdown
+	p.ContentHTML, p.TOC = markdown.RenderPost(p.Content)
+}
+
+// HideRendered 不返回渲染结果，只返回Markdown源文
+func (p *Post) HideRendered() {
+	p.ContentHTML = ""
+	p.TOC = nil
+}
+
+// BeforeSave 保存前重新渲染内容，保证源文和HTML一致
+func (p *Post) BeforeSave(tx *gorm.DB) (err error) {
+	// 只更新部分字段（如计数）时不重新渲染
+	if p.Content == "" {
+		return
+	}
+	p.Render()
+	return
+}
+
+// AfterCreate 在创建文章的同一事务中增加作者的文章数量
+func (p *Post) AfterCreate(tx *gorm.DB) (err error) {
+	return tx.Model(&User{}).Where("id = ?", p.UserID).
+		UpdateColumn("post_count", gorm.Expr("post_count + ?", 1)).Error
+}
+
+// AfterDelete 在删除文章的同一事务中减少作者的文章数量
+func (p *Post) AfterDelete(tx *gorm.DB) (err error) {
+	if p.ID == 0 || p.UserID == 0 {
+		return
+	}
+	return tx.Model(&User{}).Where("id = ? AND post_count > 0", p.UserID).
+		UpdateColumn("post_count", gorm.Expr("post_count - ?", 1)).Error
 }
 
 // PostInput 文章输入
 type PostInput struct {
 	Title   string `json:"title" binding:"required"`
 	Content string `json:"content" binding:"required"`
+	Format  string `json:"format" binding:"omitempty,oneof=markdown plain"`
+	Slug    string `json:"slug" binding:"omitempty,max=200"` // 为空时根据标题生成
+	// 评论审核策略，为空时创建的文章使用全站策略、更新时保持不变，default表示改回全站策略
+	Moderation string `json:"moderation" binding:"omitempty,oneof=default auto trusted all"`
 }
@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// 表态对象类型
+const (
+	TargetPost    = "post"
+	TargetComment = "comment"
+)
+
+// ReactionEmojis 支持的表态及对应的表情，按展示顺序排列
+var ReactionEmojis = []struct {
+	Name   string
+	Symbol string
+}{
+	{"like", "👍"},
+	{"heart", "❤️"},
+	{"laugh", "😄"},
+	{"hooray", "🎉"},
+	{"confused", "😕"},
+	{"rocket", "🚀"},
+	{"eyes", "👀"},
+}
+
+// IsReactionEmoji 判断是否为支持的表态
+func IsReactionEmoji(name string) bool {
+	for _, e := range ReactionEmojis {
+		if e.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Reaction 表态模型，同一用户对同一对象的同一表情只能表态一次
+// 取消表态直接删除记录，不使用软删除，否则唯一索引无法约束有效记录
+type Reaction struct {
+	ID         uint      `gorm:"primarykey" json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	TargetType string    `gorm:"type:varchar(20);not null;uniqueIndex:idx_reaction_unique,priority:1" json:"target_type"`
+	TargetID   uint      `gorm:"not null;uniqueIndex:idx_reaction_unique,priority:2" json:"target_id"`
+	Emoji      string    `gorm:"type:varchar(20);not null;uniqueIndex:idx_reaction_unique,priority:4" json:"emoji"`
+	UserID     uint      `gorm:"not null;uniqueIndex:idx_reaction_unique,priority:3;index" json:"user_id"`
+}
+
+// ReactionSummary 表态统计
+type ReactionSummary struct {
+	Emoji   string `json:"emoji"`
+	Symbol  string `json:"symbol"`
+	Count   int64  `json:"count"`
+	Reacted bool   `json:"reacted"` // 当前用户是否已表态，未登录时为false
+}
+
+// ReactionInput 表态输入
+type ReactionInput struct {
+	Emoji string `json:"emoji" binding:"required"`
+}
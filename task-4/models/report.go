@@ -0,0 +1,82 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 举报原因
+var ReportReasons = []string{"spam", "abuse", "harassment", "sexual", "illegal", "other"}
+
+// 举报状态
+const (
+	ReportOpen      = "open"      // 等待处理
+	ReportResolved  = "resolved"  // 举报成立，内容已隐藏
+	ReportDismissed = "dismissed" // 举报不成立，内容恢复显示
+)
+
+// Report 用户对文章或评论的举报
+// 同一用户对同一内容只能有一条未处理的举报：Open在未处理时为true，处理后置空，
+// 唯一索引不约束空值，已处理的举报保留下来作为记录
+type Report struct {
+	ID         uint       `gorm:"primarykey" json:"id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	ReporterID uint       `gorm:"not null;uniqueIndex:idx_report_open,priority:1" json:"reporter_id"`
+	Reporter   User       `gorm:"foreignKey:ReporterID" json:"reporter"`
+	TargetType string     `gorm:"type:varchar(20);not null;uniqueIndex:idx_report_open,priority:2;index:idx_report_target,priority:1" json:"target_type"`
+	TargetID   uint       `gorm:"not null;uniqueIndex:idx_report_open,priority:3;index:idx_report_target,priority:2" json:"target_id"`
+	Reason     string     `gorm:"type:varchar(20);not null" json:"reason"`
+	Detail     string     `gorm:"type:varchar(1000)" json:"detail"`
+	Status     string     `gorm:"type:varchar(20);not null;default:open;index" json:"status"`
+	Open       *bool      `gorm:"uniqueIndex:idx_report_open,priority:4" json:"-"`
+	DecidedBy  *uint      `json:"decided_by,omitempty"` // 处理举报的版主，自动隐藏时为空
+	Decider    *User      `gorm:"foreignKey:DecidedBy" json:"decider,omitempty"`
+	DecidedAt  *time.Time `json:"decided_at,omitempty"`
+	Note       string     `gorm:"type:varchar(500)" json:"note,omitempty"` // 版主的处理说明
+}
+
+// IsReportReason 判断是否为支持的举报原因
+func IsReportReason(reason string) bool {
+	for _, r := range ReportReasons {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
+
+// BeforeCreate 新举报处于未处理状态
+func (r *Report) BeforeCreate(tx *gorm.DB) (err error) {
+	open := true
+	r.Status = ReportOpen
+	r.Open = &open
+	return
+}
+
+// CloseReports 处理同一内容的所有未处理举报，返回处理的数量
+func CloseReports(tx *gorm.DB, targetType string, targetID uint, status string, moderatorID uint, note string) (int64, error) {
+	result := tx.Model(&Report{}).
+		Where("target_type = ? AND target_id = ? AND status = ?", targetType, targetID, ReportOpen).
+		UpdateColumns(map[string]interface{}{
+			"status":     status,
+			"open":       nil,
+			"decided_by": moderatorID,
+			"decided_at": time.Now(),
+			"note":       note,
+			"updated_at": time.Now(),
+		})
+	return result.RowsAffected, result.Error
+}
+
+// ReportInput 举报输入
+type ReportInput struct {
+	Reason string `json:"reason" binding:"required"`
+	Detail string `json:"detail" binding:"max=1000"`
+}
+
+// ReportDecisionInput 处理举报的输入
+type ReportDecisionInput struct {
+	Note string `json:"note" binding:"max=500"`
+}
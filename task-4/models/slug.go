@@ -0,0 +1,89 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/xhy/blog-api/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrSlugTaken 指定的别名已被其他文章使用
+var ErrSlugTaken = errors.New("别名已被其他文章使用")
+
+// PostSlug 文章使用过的所有别名，包括当前别名
+// 别名全局唯一，文章被彻底删除前不会释放，修改标题后旧别名仍然指向原文章，用于重定向
+type PostSlug struct {
+	ID        uint      `gorm:"primarykey" json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+	PostID    uint      `gorm:"index;not null" json:"post_id"`
+	Slug      string    `gorm:"type:varchar(200);uniqueIndex;not null" json:"slug"`
+}
+
+// SetSlug 设置文章的当前别名，文章必须已经保存
+// custom为true时使用作者指定的别名，被其他文章占用时返回ErrSlugTaken；
+// 否则根据标题生成，被占用时依次尝试追加-2、-3……，同样的数据总是得到同样的结果
+func (p *Post) SetSlug(tx *gorm.DB, slug string, custom bool) error {
+	base := slug
+	if !custom {
+		base = utils.Slugify(p.Title)
+		if base == "" {
+			base = "post"
+		}
+	}
+
+	for n := 1; ; n++ {
+		candidate := base
+		if n > 1 {
+			candidate = fmt.Sprintf("%s-%d", base, n)
+		}
+
+		var existing PostSlug
+		err := tx.Where("slug = ?", candidate).First(&existing).Error
+		if err == nil && existing.PostID != p.ID {
+			if custom {
+				return ErrSlugTaken
+			}
+			continue
+		}
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		// 文章自己用过的别名直接恢复，否则登记新别名
+		if err != nil {
+			result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&PostSlug{PostID: p.ID, Slug: candidate})
+			if result.Error != nil {
+				return result.Error
+			}
+			// 并发请求抢先占用了这个别名，重新检查
+			if result.RowsAffected == 0 {
+				n--
+				continue
+			}
+		}
+
+		p.Slug = candidate
+		p.SlugCustom = custom
+		return tx.Unscoped().Model(p).UpdateColumns(map[string]interface{}{"slug": candidate, "slug_custom": custom}).Error
+	}
+}
+
+// BackfillPostSlugs 为别名功能上线前创建的文章生成别名，包括已删除的文章
+func BackfillPostSlugs(db *gorm.DB) error {
+	var posts []Post
+	if err := db.Unscoped().Select("id", "title").Where("slug = '' OR slug IS NULL").Order("id").Find(&posts).Error; err != nil {
+		return err
+	}
+
+	for i := range posts {
+		if err := db.Transaction(func(tx *gorm.DB) error {
+			return posts[i].SetSlug(tx, "", false)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,12 @@
+package models
+
+// SpamDocumentsToken 记录训练样本数量的特殊词，正常的词不包含#
+const SpamDocumentsToken = "#documents"
+
+// SpamToken 垃圾内容分类器的词频：包含该词的垃圾评论和正常评论数量，
+// 由版主的审核结果训练
+type SpamToken struct {
+	Token string `gorm:"type:varchar(64);primaryKey" json:"token"`
+	Spam  int64  `gorm:"not null;default:0" json:"spam"`
+	Ham   int64  `gorm:"not null;default:0" json:"ham"`
+}
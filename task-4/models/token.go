@@ -0,0 +1,106 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 访问令牌权限范围
+const (
+	ScopePostsWrite     = "posts:write"     // 创建、修改、删除文章
+	ScopeCommentsWrite  = "comments:write"  // 发表评论，审核评论
+	ScopeReactionsWrite = "reactions:write" // 添加、取消表态
+	ScopeBookmarksWrite = "bookmarks:write" // 查看、管理收藏和收藏夹
+	ScopeFollowsWrite   = "follows:write"   // 关注、取消关注用户
+	ScopeFeedRead       = "feed:read"       // 查看关注作者的文章动态
+	ScopeReportsWrite   = "reports:write"   // 举报文章和评论，版主处理举报
+	ScopeNotifications  = "notifications"   // 查看通知、标记已读、接收实时推送
+	// ScopeTokensManage 管理访问令牌本身，只授予登录会话，不能分配给访问令牌，
+	// 避免令牌被盗用后再签发新令牌
+	ScopeTokensManage = "tokens:manage"
+	// ScopeAdmin 管理员接口，同样只授予登录会话，管理员的访问令牌不能用来管理用户和读取审计日志
+	ScopeAdmin = "admin"
+)
+
+// GrantableScopes 可以分配给个人访问令牌的权限范围
+var GrantableScopes = []string{
+	ScopePostsWrite,
+	ScopeCommentsWrite,
+	ScopeReactionsWrite,
+	ScopeBookmarksWrite,
+	ScopeFollowsWrite,
+	ScopeFeedRead,
+	ScopeReportsWrite,
+	ScopeNotifications,
+}
+
+// IsGrantableScope 判断权限范围是否可以分配给个人访问令牌
+func IsGrantableScope(scope string) bool {
+	for _, s := range GrantableScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// PersonalAccessToken 个人访问令牌模型，供脚本和CI使用
+type PersonalAccessToken struct {
+	gorm.Model
+	UserID     uint       `gorm:"index;not null" json:"user_id"`
+	User       User       `json:"-"`
+	Name       string     `gorm:"type:varchar(100);not null" json:"name"`
+	TokenHash  string     `gorm:"type:char(64);uniqueIndex;not null" json:"-"`
+	Prefix     string     `gorm:"type:varchar(20);not null" json:"prefix"`
+	Scopes     string     `gorm:"type:varchar(255);not null" json:"-"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+// ScopeList 返回令牌的权限范围列表
+func (t *PersonalAccessToken) ScopeList() []string {
+	if t.Scopes == "" {
+		return nil
+	}
+	return strings.Split(t.Scopes, ",")
+}
+
+// Expired 判断令牌是否已过期
+func (t *PersonalAccessToken) Expired(now time.Time) bool {
+	return t.ExpiresAt != nil && now.After(*t.ExpiresAt)
+}
+
+// TokenCreateInput 创建访问令牌输入
+type TokenCreateInput struct {
+	Name          string   `json:"name" binding:"required,max=100"`
+	Scopes        []string `json:"scopes" binding:"required,min=1"`
+	ExpiresInDays int      `json:"expires_in_days" binding:"omitempty,min=1,max=365"`
+}
+
+// PersonalAccessTokenResponse 访问令牌信息
+type PersonalAccessTokenResponse struct {
+	ID         uint       `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	// Token 令牌明文，只在创建时返回一次
+	Token string `json:"token,omitempty"`
+}
+
+// NewPersonalAccessTokenResponse 根据令牌模型构造响应
+func NewPersonalAccessTokenResponse(t *PersonalAccessToken) PersonalAccessTokenResponse {
+	return PersonalAccessTokenResponse{
+		ID:         t.ID,
+		Name:       t.Name,
+		Prefix:     t.Prefix,
+		Scopes:     t.ScopeList(),
+		ExpiresAt:  t.ExpiresAt,
+		LastUsedAt: t.LastUsedAt,
+		CreatedAt:  t.CreatedAt,
+	}
+}
@@ -0,0 +1,131 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TrashItem 回收站中的文章
+type TrashItem struct {
+	ID           uint      `json:"id"`
+	Title        string    `json:"title"`
+	Slug         string    `json:"slug"`
+	CommentCount int64     `json:"comment_count"`
+	CreatedAt    time.Time `json:"created_at"`
+	DeletedAt    time.Time `json:"deleted_at"`
+	PurgeAt      time.Time `json:"purge_at"` // 超过保留期限后彻底删除的时间
+}
+
+// trashCascade 随文章一起进入回收站的软删除模型
+// 表态没有软删除，文章在回收站中时无法访问，彻底删除时一并删除
+var trashCascade = []interface{}{&Comment{}, &Attachment{}}
+
+// TrashPost 将文章移入回收站，在同一事务中把文章的评论和附件一起软删除
+// 它们的删除时间与文章相同，恢复时据此区分随文章删除的和之前单独删除的记录
+// 文章的评论数量保持不变，恢复后不需要重新统计
+func TrashPost(tx *gorm.DB, p *Post) error {
+	if err := tx.Delete(p).Error; err != nil {
+		return err
+	}
+	for _, model := range trashCascade {
+		if err := tx.Model(model).Where("post_id = ?", p.ID).
+			UpdateColumn("deleted_at", p.DeletedAt.Time).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RestorePost 从回收站恢复文章及随文章删除的评论和附件，并在同一事务中增加作者的文章数量
+// 文章不在回收站中时返回gorm.ErrRecordNotFound
+func RestorePost(tx *gorm.DB, p *Post) error {
+	result := tx.Unscoped().Model(&Post{}).
+		Where("id = ? AND deleted_at = ?", p.ID, p.DeletedAt.Time).
+		UpdateColumn("deleted_at", nil)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	for _, model := range trashCascade {
+		if err := tx.Unscoped().Model(model).Where("post_id = ? AND deleted_at = ?", p.ID, p.DeletedAt.Time).
+			UpdateColumn("deleted_at", nil).Error; err != nil {
+			return err
+		}
+	}
+	p.DeletedAt = gorm.DeletedAt{}
+
+	return tx.Model(&User{}).Where("id = ?", p.UserID).
+		UpdateColumn("post_count", gorm.Expr("post_count + ?", 1)).Error
+}
+
+// PurgePosts 彻底删除文章，同时删除文章的评论、附件、表态、举报、收藏、别名和通知
+// 返回被删除的附件，调用方在事务提交后删除附件文件
+// 按条件批量删除，不触发计数钩子：文章和评论进入回收站时已经调整过计数
+func PurgePosts(tx *gorm.DB, postIDs []uint) ([]Attachment, error) {
+	if len(postIDs) == 0 {
+		return nil, nil
+	}
+
+	var commentIDs []uint
+	if err := tx.Unscoped().Model(&Comment{}).Where("post_id IN ?", postIDs).Pluck("id", &commentIDs).Error; err != nil {
+		return nil, err
+	}
+	if err := purgeCommentRelations(tx, commentIDs); err != nil {
+		return nil, err
+	}
+
+	var attachments []Attachment
+	if err := tx.Unscoped().Where("post_id IN ?", postIDs).Find(&attachments).Error; err != nil {
+		return nil, err
+	}
+
+	steps := []struct {
+		model interface{}
+		query string
+		args  []interface{}
+	}{
+		{&Reaction{}, "target_type = ? AND target_id IN ?", []interface{}{TargetPost, postIDs}},
+		{&Report{}, "target_type = ? AND target_id IN ?", []interface{}{TargetPost, postIDs}},
+		{&Notification{}, "post_id IN ?", []interface{}{postIDs}},
+		{&Bookmark{}, "post_id IN ?", []interface{}{postIDs}},
+		{&Attachment{}, "post_id IN ?", []interface{}{postIDs}},
+		{&Comment{}, "post_id IN ?", []interface{}{postIDs}},
+		{&PostSlug{}, "post_id IN ?", []interface{}{postIDs}},
+		{&Post{}, "id IN ?", []interface{}{postIDs}},
+	}
+	for _, step := range steps {
+		if err := tx.Unscoped().Where(step.query, step.args...).Delete(step.model).Error; err != nil {
+			return nil, err
+		}
+	}
+	return attachments, nil
+}
+
+// PurgeComments 彻底删除评论及其表态、举报和通知
+func PurgeComments(tx *gorm.DB, commentIDs []uint) error {
+	if len(commentIDs) == 0 {
+		return nil
+	}
+	if err := purgeCommentRelations(tx, commentIDs); err != nil {
+		return err
+	}
+	return tx.Unscoped().Where("id IN ?", commentIDs).Delete(&Comment{}).Error
+}
+
+// purgeCommentRelations 删除评论的表态、举报和通知
+func purgeCommentRelations(tx *gorm.DB, commentIDs []uint) error {
+	if len(commentIDs) == 0 {
+		return nil
+	}
+	if err := tx.Where("target_type = ? AND target_id IN ?", TargetComment, commentIDs).Delete(&Reaction{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("target_type = ? AND target_id IN ?", TargetComment, commentIDs).Delete(&Report{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("comment_id IN ?", commentIDs).Delete(&Notification{}).Error
+}
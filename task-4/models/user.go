@@ -1,16 +1,57 @@
 package models
 
 import (
+	"time"
+
 	"gorm.io/gorm"
 )
 
+// 用户角色
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator" // 可以审核所有文章的评论
+	RoleAdmin     = "admin"     // 拥有版主的全部权限
+)
+
 // User 用户模型
 type User struct {
 	gorm.Model
-	Username string `gorm:"type:varchar(100);uniqueIndex;not null" json:"username"`
-	Password string `gorm:"type:varchar(255);not null" json:"-"`
-	Email    string `gorm:"type:varchar(100);uniqueIndex;not null" json:"email"`
-	Posts    []Post `json:"posts,omitempty"`
+	Username  string `gorm:"type:varchar(100);uniqueIndex;not null" json:"username"`
+	Password  string `gorm:"type:varchar(255);not null" json:"-"`
+	Email     string `gorm:"type:varchar(100);uniqueIndex;not null" json:"email"`
+	Role      string `gorm:"type:varchar(20);not null;default:user" json:"role"`
+	PostCount int64  `gorm:"not null;default:0" json:"post_count"` // 发布的文章数量，随文章创建、删除更新
+	Posts     []Post `json:"posts,omitempty"`
+
+	SuspendedAt       *time.Time `json:"-"` // 被管理员停用的时间，停用期间无法登录和访问需要认证的接口，只通过ManagedUser返回给管理员
+	SuspendReason     string     `gorm:"type:varchar(255)" json:"-"`
+	SessionsRevokedAt *time.Time `json:"-"` // 强制下线的时间，此前签发的JWT全部失效
+}
+
+// IsModerator 判断用户是否可以审核所有评论
+func (u *User) IsModerator() bool {
+	return u.Role == RoleModerator || u.Role == RoleAdmin
+}
+
+// IsSuspended 判断用户是否被停用
+func (u *User) IsSuspended() bool {
+	return u.SuspendedAt != nil
+}
+
+// SessionRevoked 判断在issuedAt签发的登录令牌是否已被强制下线
+// JWT的签发时间只精确到秒，与下线时间在同一秒内签发的令牌同样失效
+func (u *User) SessionRevoked(issuedAt time.Time) bool {
+	return u.SessionsRevokedAt != nil && !issuedAt.After(u.SessionsRevokedAt.Truncate(time.Second))
+}
+
+// IsAdmin 判断用户是否为管理员
+func (u *User) IsAdmin() bool {
+	return u.Role == RoleAdmin
+}
+
+// IsValidRole 判断是否为支持的角色
+func IsValidRole(role string) bool {
+	return role == RoleUser || role == RoleModerator || role == RoleAdmin
 }
 
 // UserRegisterInput 用户注册输入
@@ -0,0 +1,55 @@
+package notify
+
+import (
+	"sync"
+
+	"github.com/xhy/blog-api/models"
+)
+
+// 每个订阅的缓冲大小，客户端处理不过来时丢弃新通知，客户端重连时可以通过Last-Event-ID补齐
+const subscriberBuffer = 16
+
+// Hub 进程内的通知分发中心，按接收者投递给实时推送连接
+// 多实例部署时每个实例只能推送到连接在本实例上的客户端
+type Hub struct {
+	mu          sync.RWMutex
+	subscribers map[uint]map[chan models.Notification]struct{}
+}
+
+// NewHub 创建通知分发中心
+func NewHub() *Hub {
+	return &Hub{subscribers: make(map[uint]map[chan models.Notification]struct{})}
+}
+
+// Subscribe 订阅指定用户的通知，调用返回的函数取消订阅
+func (h *Hub) Subscribe(userID uint) (<-chan models.Notification, func()) {
+	ch := make(chan models.Notification, subscriberBuffer)
+
+	h.mu.Lock()
+	if h.subscribers[userID] == nil {
+		h.subscribers[userID] = make(map[chan models.Notification]struct{})
+	}
+	h.subscribers[userID][ch] = struct{}{}
+	h.mu.Unlock()
+
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		delete(h.subscribers[userID], ch)
+		if len(h.subscribers[userID]) == 0 {
+			delete(h.subscribers, userID)
+		}
+	}
+}
+
+// Publish 将通知投递给接收者的所有连接，不会阻塞
+func (h *Hub) Publish(n models.Notification) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for ch := range h.subscribers[n.RecipientID] {
+		select {
+		case ch <- n:
+		default:
+		}
+	}
+}
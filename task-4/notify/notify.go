@@ -0,0 +1,27 @@
+package notify
+
+import (
+	"log"
+
+	"github.com/xhy/blog-api/config"
+	"github.com/xhy/blog-api/models"
+)
+
+// Default 默认的通知分发中心
+var Default = NewHub()
+
+// Send 保存通知并实时推送给接收者，自己触发的事件不通知自己
+// 通知失败不影响触发它的操作，只记录日志
+func Send(n models.Notification) {
+	if n.RecipientID == 0 || n.RecipientID == n.ActorID {
+		return
+	}
+
+	if err := config.DB.Create(&n).Error; err != nil {
+		log.Printf("保存通知失败: %v", err)
+		return
+	}
+	config.DB.First(&n.Actor, n.ActorID)
+
+	Default.Publish(n)
+}
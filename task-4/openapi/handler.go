@@ -0,0 +1,75 @@
+package openapi
+
+import (
+	"io/fs"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files/v2"
+	"github.com/xhy/blog-api/config"
+)
+
+// Handler 返回OpenAPI文档的接口
+// 文档在首次请求时根据engine的路由表生成，此时所有路由都已注册
+func Handler(engine *gin.Engine, docs map[string]Route) gin.HandlerFunc {
+	var once sync.Once
+	var doc *Document
+	return func(c *gin.Context) {
+		once.Do(func() {
+			site := config.GetConfig().Site
+			var problems []string
+			doc, problems = Build(Info{
+				Title:       site.Title + " API",
+				Description: site.Description,
+				Version:     "1.0.0",
+			}, []Server{{URL: site.URL}}, engine.Routes(), docs)
+			for _, problem := range problems {
+				log.Printf("OpenAPI文档: %s", problem)
+			}
+		})
+		c.JSON(http.StatusOK, doc)
+	}
+}
+
+// SwaggerUI 返回Swagger UI页面和静态资源，路由须以/*filepath结尾，specURL为OpenAPI文档的地址
+// 页面中的资源使用相对路径，访问时目录地址须以/结尾（gin会把不带/的地址重定向过来）
+func SwaggerUI(specURL string) gin.HandlerFunc {
+	// 替换Swagger UI自带的初始化脚本，加载本服务的文档
+	initializer := `window.onload = function() {
+  window.ui = SwaggerUIBundle({
+    url: ` + strconv.Quote(specURL) + `,
+    dom_id: '#swagger-ui',
+    deepLinking: true,
+    presets: [SwaggerUIBundle.presets.apis, SwaggerUIStandalonePreset],
+    plugins: [SwaggerUIBundle.plugins.DownloadUrl],
+    layout: "StandaloneLayout"
+  });
+};
+`
+	index, err := fs.ReadFile(swaggerFiles.FS, "index.html")
+	if err != nil {
+		panic(err)
+	}
+	files := http.FileServer(http.FS(swaggerFiles.FS))
+
+	return func(c *gin.Context) {
+		file := strings.TrimPrefix(c.Param("filepath"), "/")
+		switch file {
+		case "", "index.html":
+			// FileServer会把index.html重定向到目录地址，直接返回页面
+			c.Data(http.StatusOK, "text/html; charset=utf-8", index)
+			return
+		case "swagger-initializer.js":
+			c.Data(http.StatusOK, "application/javascript; charset=utf-8", []byte(initializer))
+			return
+		}
+
+		req := c.Request.Clone(c.Request.Context())
+		req.URL.Path = "/" + file
+		files.ServeHTTP(c.Writer, req)
+	}
+}
@@ -0,0 +1,310 @@
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xhy/blog-api/models"
+)
+
+// Auth 接口的认证方式
+type Auth int
+
+const (
+	AuthNone     Auth = iota // 不需要认证
+	AuthOptional             // 可以匿名访问，登录后返回与当前用户相关的内容
+	AuthRequired             // 需要JWT或个人访问令牌
+	AuthQuery                // 需要认证，令牌也可以通过access_token查询参数传递
+)
+
+// Param 查询参数
+type Param struct {
+	Name        string
+	Type        string // string、integer或boolean，默认string
+	Description string
+	Required    bool
+}
+
+// Route 接口说明，以"方法 路径"为键登记，路径与gin路由相同
+type Route struct {
+	Tag         string
+	Summary     string
+	Description string
+	Auth        Auth
+	Role        string      // 需要的角色，为空时不限角色
+	Scope       string      // 个人访问令牌需要的权限范围
+	Query       []Param     // 查询参数
+	Body        interface{} // JSON请求体的结构
+	Upload      string      // multipart/form-data上传的文件字段名
+	Status      int         // 成功时的状态码，默认200
+	Data        interface{} // 成功时models.Response中data的结构，为nil时没有data
+	Content     string      // 不使用models.Response包装的响应内容类型，如订阅源、站点地图和附件
+}
+
+// Key 接口说明的键
+func Key(method, path string) string {
+	return method + " " + path
+}
+
+// FormParams 根据结构体的form标签生成查询参数，用于通过ShouldBindQuery绑定的查询条件
+func FormParams(v interface{}) []Param {
+	t := reflect.TypeOf(v)
+	var params []Param
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := field.Tag.Get("form")
+		if name == "" || name == "-" {
+			continue
+		}
+		param := Param{Name: name, Required: strings.Contains(field.Tag.Get("binding"), "required")}
+		switch field.Type.Kind() {
+		case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+			param.Type = "integer"
+		case reflect.Bool:
+			param.Type = "boolean"
+		}
+		params = append(params, param)
+	}
+	return params
+}
+
+// Build 根据gin的路由表和接口说明生成文档
+// 每个路由都会出现在文档中；problems列出没有说明的路由和没有对应路由的说明
+func Build(info Info, servers []Server, routes gin.RoutesInfo, docs map[string]Route) (*Document, []string) {
+	s := &schemas{components: make(map[string]*Schema)}
+	doc := &Document{
+		OpenAPI: "3.0.3",
+		Info:    info,
+		Servers: servers,
+		Paths:   make(map[string]PathItem),
+		Components: Components{
+			Schemas: s.components,
+			Responses: map[string]*Response{
+				"Error":        errorResponse("请求失败，message为错误原因"),
+				"Unauthorized": errorResponse("未认证、令牌无效或已过期"),
+				"Forbidden":    errorResponse("没有权限，或访问令牌缺少所需的权限范围"),
+			},
+			SecuritySchemes: map[string]*SecurityScheme{
+				"bearerAuth": {
+					Type:        "http",
+					Scheme:      "bearer",
+					Description: "登录获得的JWT，或个人访问令牌",
+				},
+				"queryToken": {
+					Type:        "apiKey",
+					In:          "query",
+					Name:        "access_token",
+					Description: "EventSource无法设置请求头，实时推送接口允许通过查询参数传递令牌",
+				},
+			},
+		},
+	}
+	// 通用响应结构，data的结构由各个接口给出
+	s.of(models.Response{})
+
+	var problems []string
+	documented := make(map[string]bool, len(docs))
+	operationIDs := make(map[string]bool, len(routes))
+	tags := make(map[string]bool)
+	for _, route := range routes {
+		key := Key(route.Method, route.Path)
+		spec, ok := docs[key]
+		if !ok {
+			problems = append(problems, "路由没有接口说明: "+key)
+		}
+		documented[key] = true
+
+		op := s.operation(route, spec)
+		op.OperationID = operationID(route, operationIDs)
+		if spec.Tag != "" && !tags[spec.Tag] {
+			tags[spec.Tag] = true
+			doc.Tags = append(doc.Tags, Tag{Name: spec.Tag})
+		}
+
+		path := openAPIPath(route.Path)
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(PathItem)
+		}
+		doc.Paths[path][strings.ToLower(route.Method)] = op
+	}
+
+	for key := range docs {
+		if !documented[key] {
+			problems = append(problems, "接口说明没有对应的路由: "+key)
+		}
+	}
+	sort.Strings(problems)
+	return doc, problems
+}
+
+// operation 生成一个接口
+func (s *schemas) operation(route gin.RouteInfo, spec Route) *Operation {
+	op := &Operation{
+		Summary:    spec.Summary,
+		Parameters: pathParams(route.Path),
+		Responses:  make(map[string]*Response),
+		Scope:      spec.Scope,
+	}
+	if spec.Tag != "" {
+		op.Tags = []string{spec.Tag}
+	}
+
+	var notes []string
+	if spec.Description != "" {
+		notes = append(notes, spec.Description)
+	}
+	if spec.Role != "" {
+		notes = append(notes, "需要"+spec.Role+"角色")
+	}
+	if spec.Scope != "" {
+		notes = append(notes, "使用个人访问令牌时需要"+spec.Scope+"权限范围")
+	}
+	op.Description = strings.Join(notes, "；")
+
+	for _, param := range spec.Query {
+		schemaType := param.Type
+		if schemaType == "" {
+			schemaType = "string"
+		}
+		op.Parameters = append(op.Parameters, Parameter{
+			Name:        param.Name,
+			In:          "query",
+			Description: param.Description,
+			Required:    param.Required,
+			Schema:      &Schema{Type: schemaType},
+		})
+	}
+
+	switch {
+	case spec.Upload != "":
+		op.RequestBody = &RequestBody{Required: true, Content: map[string]MediaType{
+			"multipart/form-data": {Schema: &Schema{
+				Type:       "object",
+				Properties: map[string]*Schema{spec.Upload: {Type: "string", Format: "binary"}},
+				Required:   []string{spec.Upload},
+			}},
+		}}
+	case spec.Body != nil:
+		op.RequestBody = &RequestBody{Required: hasRequired(spec.Body), Content: map[string]MediaType{
+			"application/json": {Schema: s.of(spec.Body)},
+		}}
+	}
+
+	status := spec.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	op.Responses[strconv.Itoa(status)] = s.success(spec)
+	op.Responses["default"] = &Response{Ref: "#/components/responses/Error"}
+
+	switch spec.Auth {
+	case AuthOptional:
+		op.Security = []SecurityRequirement{{}, {"bearerAuth": {}}}
+	case AuthRequired:
+		op.Security = []SecurityRequirement{{"bearerAuth": {}}}
+	case AuthQuery:
+		op.Security = []SecurityRequirement{{"bearerAuth": {}}, {"queryToken": {}}}
+	}
+	if spec.Auth == AuthRequired || spec.Auth == AuthQuery {
+		op.Responses["401"] = &Response{Ref: "#/components/responses/Unauthorized"}
+		op.Responses["403"] = &Response{Ref: "#/components/responses/Forbidden"}
+	}
+	return op
+}
+
+// success 成功响应，JSON接口的data放在models.Response中
+func (s *schemas) success(spec Route) *Response {
+	if spec.Content != "" {
+		var schema *Schema
+		switch {
+		case strings.Contains(spec.Content, "json") && spec.Data != nil:
+			schema = s.of(spec.Data)
+		case strings.Contains(spec.Content, "json"):
+			schema = &Schema{Type: "object"}
+		case spec.Content == "application/octet-stream":
+			schema = &Schema{Type: "string", Format: "binary"}
+		default:
+			schema = &Schema{Type: "string"}
+		}
+		return &Response{Description: "成功", Content: map[string]MediaType{spec.Content: {Schema: schema}}}
+	}
+
+	schema := ref("Response")
+	if spec.Data != nil {
+		schema = &Schema{AllOf: []*Schema{schema, {
+			Type:       "object",
+			Properties: map[string]*Schema{"data": s.of(spec.Data)},
+		}}}
+	}
+	return &Response{Description: "成功", Content: map[string]MediaType{"application/json": {Schema: schema}}}
+}
+
+// hasRequired 判断请求体是否有必填字段，没有时请求体可以省略（如停用原因、处理说明）
+func hasRequired(body interface{}) bool {
+	t := reflect.TypeOf(body)
+	if t.Kind() != reflect.Struct {
+		return true
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if strings.Contains(t.Field(i).Tag.Get("binding"), "required") {
+			return true
+		}
+	}
+	return false
+}
+
+// errorResponse 错误响应，与成功响应使用相同的models.Response结构
+func errorResponse(description string) *Response {
+	return &Response{Description: description, Content: map[string]MediaType{
+		"application/json": {Schema: ref("Response")},
+	}}
+}
+
+// openAPIPath 把gin的路径参数（:id、*filepath）转换为OpenAPI的{id}
+func openAPIPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// pathParams 路径参数，名为id的参数是整数
+func pathParams(path string) []Parameter {
+	var params []Parameter
+	for _, segment := range strings.Split(path, "/") {
+		if !strings.HasPrefix(segment, ":") && !strings.HasPrefix(segment, "*") {
+			continue
+		}
+		schema := &Schema{Type: "string"}
+		if segment[1:] == "id" {
+			schema = &Schema{Type: "integer", Format: "int64", Minimum: intPtr(1)}
+		}
+		params = append(params, Parameter{Name: segment[1:], In: "path", Required: true, Schema: schema})
+	}
+	return params
+}
+
+// operationID 使用处理函数名作为operationId，闭包或重名时由方法和路径生成
+func operationID(route gin.RouteInfo, used map[string]bool) string {
+	name := route.Handler[strings.LastIndex(route.Handler, "/")+1:]
+	name = name[strings.LastIndex(name, ".")+1:]
+	if name == "" || strings.HasPrefix(name, "func") || used[name] {
+		var b strings.Builder
+		b.WriteString(strings.ToLower(route.Method))
+		for _, part := range strings.FieldsFunc(route.Path, func(r rune) bool {
+			return r == '/' || r == ':' || r == '*' || r == '.' || r == '-' || r == '_'
+		}) {
+			b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+		}
+		name = b.String()
+	}
+	used[name] = true
+	return name
+}
@@ -0,0 +1,185 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 按JSON编码结果特殊处理的类型
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	deletedAtType = reflect.TypeOf(gorm.DeletedAt{})
+	rawType       = reflect.TypeOf(json.RawMessage{})
+)
+
+// schemas 根据Go类型生成JSON Schema，具名结构体登记到components中并通过$ref引用
+type schemas struct {
+	components map[string]*Schema
+}
+
+// ref 引用components中的结构
+func ref(name string) *Schema {
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// of 返回值v的类型对应的结构，v为nil时表示任意值
+func (s *schemas) of(v interface{}) *Schema {
+	if v == nil {
+		return &Schema{}
+	}
+	return s.schema(reflect.TypeOf(v))
+}
+
+// schema 返回类型对应的结构，字段按encoding/json的规则处理
+func (s *schemas) schema(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case deletedAtType:
+		return &Schema{Type: "string", Format: "date-time", Nullable: true}
+	case rawType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64", Minimum: intPtr(0)}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: s.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: s.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.object(t)
+		}
+		// 先登记名称再生成字段，互相引用的结构（文章和用户）不会无限递归
+		if _, ok := s.components[t.Name()]; !ok {
+			s.components[t.Name()] = &Schema{}
+			*s.components[t.Name()] = *s.object(t)
+		}
+		return ref(t.Name())
+	}
+	return &Schema{}
+}
+
+// object 生成结构体的字段，没有json标签的匿名字段（如gorm.Model）展开到外层
+func (s *schemas) object(t reflect.Type) *Schema {
+	obj := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	s.fields(t, obj)
+	return obj
+}
+
+// fields 把结构体的字段加入obj
+func (s *schemas) fields(t reflect.Type, obj *Schema) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				s.fields(embedded, obj)
+				continue
+			}
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		prop := s.schema(field.Type)
+		if field.Type.Kind() == reflect.Pointer && prop.Ref == "" {
+			prop.Nullable = true
+		}
+		if required := applyBinding(prop, field.Tag.Get("binding")); required {
+			obj.Required = append(obj.Required, name)
+		}
+		obj.Properties[name] = prop
+	}
+}
+
+// applyBinding 把binding标签中的校验规则转换为结构约束，返回字段是否必填
+// 只处理接口输入中用到的required、min、max、oneof和email
+func applyBinding(prop *Schema, binding string) bool {
+	if binding == "" || prop.Ref != "" {
+		return false
+	}
+
+	required := false
+	for _, rule := range strings.Split(binding, ",") {
+		key, value, _ := strings.Cut(rule, "=")
+		switch key {
+		case "required":
+			required = true
+		case "email":
+			prop.Format = "email"
+		case "oneof":
+			prop.Enum = strings.Fields(value)
+		case "min", "max":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				continue
+			}
+			setBound(prop, key == "min", n)
+		}
+	}
+	return required
+}
+
+// setBound 按字段类型设置长度、数量或数值的上下限
+func setBound(prop *Schema, lower bool, n int) {
+	var bound **int
+	switch prop.Type {
+	case "string":
+		bound = &prop.MaxLength
+		if lower {
+			bound = &prop.MinLength
+		}
+	case "array":
+		bound = &prop.MaxItems
+		if lower {
+			bound = &prop.MinItems
+		}
+	case "integer", "number":
+		bound = &prop.Maximum
+		if lower {
+			bound = &prop.Minimum
+		}
+	default:
+		return
+	}
+	*bound = intPtr(n)
+}
+
+// intPtr 返回整数的指针
+func intPtr(n int) *int {
+	return &n
+}
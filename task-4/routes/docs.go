@@ -0,0 +1,301 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/xhy/blog-api/graph"
+	"github.com/xhy/blog-api/models"
+	"github.com/xhy/blog-api/openapi"
+)
+
+// 分页参数，与controllers中的parsePagination一致
+var pagination = []openapi.Param{
+	{Name: "page", Type: "integer", Description: "页码，从1开始"},
+	{Name: "pageSize", Type: "integer", Description: "每页数量，默认10，最多100"},
+}
+
+// paged 在分页参数之后追加其他查询参数
+func paged(params ...openapi.Param) []openapi.Param {
+	return append(append([]openapi.Param{}, pagination...), params...)
+}
+
+// 删除用户、强制下线和处理举报返回的数量
+type (
+	deletedContent struct {
+		Posts    int `json:"posts"`
+		Comments int `json:"comments"`
+	}
+	revokedTokens struct {
+		RevokedTokens int64 `json:"revoked_tokens"`
+	}
+	closedReports struct {
+		Closed int64 `json:"closed"`
+	}
+)
+
+// apiDocs 每个路由的接口说明，用于生成OpenAPI文档，新增路由时须同时登记
+var apiDocs = map[string]openapi.Route{
+	// JWT验证公钥
+	openapi.Key(http.MethodGet, "/.well-known/jwks.json"): {Tag: "认证", Summary: "获取JWT验证公钥（JWK Set）", Content: "application/json"},
+
+	// 订阅源
+	openapi.Key(http.MethodGet, "/feed.xml"):           {Tag: "订阅源", Summary: "全站最新文章的RSS 2.0订阅源", Content: "application/rss+xml"},
+	openapi.Key(http.MethodGet, "/atom.xml"):           {Tag: "订阅源", Summary: "全站最新文章的Atom订阅源", Content: "application/atom+xml"},
+	openapi.Key(http.MethodGet, "/users/:id/feed.xml"): {Tag: "订阅源", Summary: "作者最新文章的RSS 2.0订阅源", Content: "application/rss+xml"},
+
+	// 搜索引擎
+	openapi.Key(http.MethodGet, "/sitemap.xml"):    {Tag: "站点地图", Summary: "站点地图，URL过多时为站点地图索引", Content: "application/xml"},
+	openapi.Key(http.MethodGet, "/sitemaps/:file"): {Tag: "站点地图", Summary: "站点地图分页", Description: "file为从1开始的页码加.xml后缀", Content: "application/xml"},
+	openapi.Key(http.MethodGet, "/robots.txt"):     {Tag: "站点地图", Summary: "爬虫协议", Content: "text/plain"},
+	openapi.Key(http.MethodGet, "/openapi.json"):   {Tag: "接口文档", Summary: "OpenAPI 3接口文档", Content: "application/json"},
+	openapi.Key(http.MethodGet, "/docs/*filepath"): {Tag: "接口文档", Summary: "Swagger UI页面和静态资源", Content: "text/html"},
+	openapi.Key(http.MethodPost, "/graphql"):       {Tag: "GraphQL", Summary: "GraphQL接口", Body: graph.Params{}, Content: "application/json"},
+
+	// 用户认证
+	openapi.Key(http.MethodPost, "/api/register"): {Tag: "认证", Summary: "用户注册", Body: models.UserRegisterInput{}, Status: http.StatusCreated},
+	openapi.Key(http.MethodPost, "/api/login"):    {Tag: "认证", Summary: "用户登录", Body: models.UserLoginInput{}, Data: models.TokenResponse{}},
+
+	// 文章
+	openapi.Key(http.MethodGet, "/api/posts"): {
+		Tag: "文章", Summary: "获取文章列表，最新的在前",
+		Query: pagination, Data: []models.Post{},
+	},
+	openapi.Key(http.MethodGet, "/api/posts/:id"): {
+		Tag: "文章", Summary: "获取文章",
+		Query: []openapi.Param{{Name: "render", Description: "为html时同时返回过滤后的HTML和目录"}},
+		Data:  models.Post{},
+	},
+	openapi.Key(http.MethodGet, "/api/posts/by-slug/:slug"): {
+		Tag: "文章", Summary: "按别名获取文章", Description: "使用旧别名访问时重定向到当前别名",
+		Query: []openapi.Param{{Name: "render", Description: "为html时同时返回过滤后的HTML和目录"}},
+		Data:  models.Post{},
+	},
+	openapi.Key(http.MethodPost, "/api/posts"): {
+		Tag: "文章", Summary: "创建文章",
+		Body: models.PostInput{}, Status: http.StatusCreated, Data: models.Post{},
+	},
+	openapi.Key(http.MethodPut, "/api/posts/:id"): {
+		Tag: "文章", Summary: "更新文章", Description: "只有作者可以修改",
+		Body: models.PostInput{}, Data: models.Post{},
+	},
+	openapi.Key(http.MethodDelete, "/api/posts/:id"): {
+		Tag: "文章", Summary: "删除文章", Description: "文章连同评论和附件移入回收站，只有作者可以删除",
+	},
+	openapi.Key(http.MethodPost, "/api/posts/:id/restore"): {
+		Tag: "回收站", Summary: "从回收站恢复文章", Data: models.Post{},
+	},
+
+	// 评论
+	openapi.Key(http.MethodGet, "/api/posts/:id/comments"): {
+		Tag: "评论", Summary: "获取文章的评论", Description: "包括当前用户自己等待审核的评论", Data: []models.Comment{},
+	},
+	openapi.Key(http.MethodPost, "/api/posts/:id/comments"): {
+		Tag: "评论", Summary: "发表评论", Description: "需要审核的评论status为pending",
+		Body: models.CommentInput{}, Status: http.StatusCreated, Data: models.Comment{},
+	},
+	openapi.Key(http.MethodGet, "/api/moderation/comments"): {
+		Tag: "评论审核", Summary: "获取评论审核队列", Description: "版主可以看到所有文章的评论，作者只能看到自己文章的评论",
+		Query: paged(
+			openapi.Param{Name: "status", Description: "pending（默认）、approved、rejected或spam"},
+			openapi.Param{Name: "post_id", Type: "integer"},
+		),
+		Data: []models.Comment{},
+	},
+	openapi.Key(http.MethodPost, "/api/moderation/comments"): {
+		Tag: "评论审核", Summary: "批量审核评论",
+		Body: models.ModerationInput{}, Data: models.ModerationResult{},
+	},
+
+	// 举报
+	openapi.Key(http.MethodPost, "/api/posts/:id/report"): {
+		Tag: "举报", Summary: "举报文章",
+		Body: models.ReportInput{}, Status: http.StatusCreated, Data: models.Report{},
+	},
+	openapi.Key(http.MethodPost, "/api/comments/:id/report"): {
+		Tag: "举报", Summary: "举报评论",
+		Body: models.ReportInput{}, Status: http.StatusCreated, Data: models.Report{},
+	},
+	openapi.Key(http.MethodGet, "/api/moderation/reports"): {
+		Tag: "举报", Summary: "获取举报队列", Role: models.RoleModerator,
+		Query: paged(
+			openapi.Param{Name: "status", Description: "open（默认）、resolved或dismissed"},
+			openapi.Param{Name: "target_type", Description: "post或comment"},
+			openapi.Param{Name: "target_id", Type: "integer"},
+		),
+		Data: []models.Report{},
+	},
+	openapi.Key(http.MethodPost, "/api/moderation/reports/:id/resolve"): {
+		Tag: "举报", Summary: "举报成立，隐藏内容", Role: models.RoleModerator,
+		Body: models.ReportDecisionInput{}, Data: closedReports{},
+	},
+	openapi.Key(http.MethodPost, "/api/moderation/reports/:id/dismiss"): {
+		Tag: "举报", Summary: "举报不成立，恢复被自动隐藏的内容", Role: models.RoleModerator,
+		Body: models.ReportDecisionInput{}, Data: closedReports{},
+	},
+
+	// 表态
+	openapi.Key(http.MethodPost, "/api/posts/:id/reactions"): {
+		Tag: "表态", Summary: "对文章表态", Description: "新增表态返回201，已表态时返回200",
+		Body: models.ReactionInput{}, Status: http.StatusCreated, Data: []models.ReactionSummary{},
+	},
+	openapi.Key(http.MethodDelete, "/api/posts/:id/reactions/:emoji"): {
+		Tag: "表态", Summary: "取消对文章的表态", Data: []models.ReactionSummary{},
+	},
+	openapi.Key(http.MethodPost, "/api/comments/:id/reactions"): {
+		Tag: "表态", Summary: "对评论表态", Description: "新增表态返回201，已表态时返回200",
+		Body: models.ReactionInput{}, Status: http.StatusCreated, Data: []models.ReactionSummary{},
+	},
+	openapi.Key(http.MethodDelete, "/api/comments/:id/reactions/:emoji"): {
+		Tag: "表态", Summary: "取消对评论的表态", Data: []models.ReactionSummary{},
+	},
+
+	// 用户与关注
+	openapi.Key(http.MethodGet, "/api/users/:id"): {
+		Tag: "用户", Summary: "获取用户主页信息", Data: models.UserProfile{},
+	},
+	openapi.Key(http.MethodGet, "/api/users/:id/followers"): {
+		Tag: "用户", Summary: "获取用户的粉丝列表", Query: pagination, Data: []models.UserBrief{},
+	},
+	openapi.Key(http.MethodGet, "/api/users/:id/following"): {
+		Tag: "用户", Summary: "获取用户的关注列表", Query: pagination, Data: []models.UserBrief{},
+	},
+	openapi.Key(http.MethodPost, "/api/users/:id/follow"): {
+		Tag: "用户", Summary: "关注用户",
+	},
+	openapi.Key(http.MethodDelete, "/api/users/:id/follow"): {
+		Tag: "用户", Summary: "取消关注",
+	},
+	openapi.Key(http.MethodGet, "/api/feed"): {
+		Tag: "用户", Summary: "关注作者的文章动态", Description: "使用游标分页，next_cursor为空表示没有更多数据",
+		Query: []openapi.Param{
+			{Name: "limit", Type: "integer", Description: "每页数量，默认20，最多100"},
+			{Name: "cursor", Description: "上一页返回的next_cursor"},
+		},
+		Data: models.FeedResponse{},
+	},
+
+	// 通知
+	openapi.Key(http.MethodGet, "/api/notifications"): {
+		Tag: "通知", Summary: "获取通知列表",
+		Query: paged(openapi.Param{Name: "unread", Type: "boolean", Description: "为true时只返回未读通知"}),
+		Data:  models.NotificationListResponse{},
+	},
+	openapi.Key(http.MethodPost, "/api/notifications/:id/read"): {
+		Tag: "通知", Summary: "将通知标记为已读",
+	},
+	openapi.Key(http.MethodPost, "/api/notifications/read-all"): {
+		Tag: "通知", Summary: "将全部通知标记为已读",
+	},
+	openapi.Key(http.MethodGet, "/api/notifications/stream"): {
+		Tag: "通知", Summary: "实时推送通知（Server-Sent Events）", Description: "重连时通过Last-Event-ID请求头补发错过的通知",
+		Content: "text/event-stream",
+	},
+
+	// 收藏
+	openapi.Key(http.MethodPost, "/api/posts/:id/bookmark"): {
+		Tag: "收藏", Summary: "收藏文章", Description: "已收藏时移动到指定的收藏夹",
+		Body: models.BookmarkInput{},
+	},
+	openapi.Key(http.MethodDelete, "/api/posts/:id/bookmark"): {
+		Tag: "收藏", Summary: "取消收藏",
+	},
+	openapi.Key(http.MethodGet, "/api/me/bookmarks"): {
+		Tag: "收藏", Summary: "获取收藏列表",
+		Query: paged(openapi.Param{Name: "collection_id", Type: "integer", Description: "只返回指定收藏夹中的收藏"}),
+		Data:  []models.BookmarkResponse{},
+	},
+	openapi.Key(http.MethodGet, "/api/me/collections"): {
+		Tag: "收藏", Summary: "获取收藏夹列表",
+		Data: []models.BookmarkCollection{},
+	},
+	openapi.Key(http.MethodPost, "/api/me/collections"): {
+		Tag: "收藏", Summary: "创建收藏夹",
+		Body: models.CollectionInput{}, Status: http.StatusCreated, Data: models.BookmarkCollection{},
+	},
+	openapi.Key(http.MethodDelete, "/api/me/collections/:id"): {
+		Tag: "收藏", Summary: "删除收藏夹", Description: "其中的收藏移回默认列表",
+	},
+
+	// 回收站
+	openapi.Key(http.MethodGet, "/api/me/trash"): {
+		Tag: "回收站", Summary: "获取回收站中的文章",
+		Query: pagination, Data: []models.TrashItem{},
+	},
+	openapi.Key(http.MethodDelete, "/api/me/trash/:id"): {
+		Tag: "回收站", Summary: "彻底删除回收站中的文章",
+	},
+
+	// 附件
+	openapi.Key(http.MethodGet, "/api/posts/:id/attachments"): {
+		Tag: "附件", Summary: "获取文章的附件列表", Data: []models.Attachment{},
+	},
+	openapi.Key(http.MethodGet, "/api/attachments/:id"): {
+		Tag: "附件", Summary: "下载附件", Content: "application/octet-stream",
+	},
+	openapi.Key(http.MethodGet, "/api/attachments/:id/thumbnail"): {
+		Tag: "附件", Summary: "获取图片附件的缩略图", Content: "application/octet-stream",
+	},
+	openapi.Key(http.MethodPost, "/api/posts/:id/attachments"): {
+		Tag: "附件", Summary: "上传附件", Description: "只有文章作者可以上传",
+		Upload: "file", Status: http.StatusCreated, Data: models.Attachment{},
+	},
+	openapi.Key(http.MethodDelete, "/api/attachments/:id"): {
+		Tag: "附件", Summary: "删除附件",
+	},
+
+	// 个人访问令牌，只接受登录获得的JWT
+	openapi.Key(http.MethodGet, "/api/me/tokens"): {
+		Tag: "访问令牌", Summary: "获取个人访问令牌列表",
+		Data: []models.PersonalAccessTokenResponse{},
+	},
+	openapi.Key(http.MethodPost, "/api/me/tokens"): {
+		Tag: "访问令牌", Summary: "创建个人访问令牌", Description: "令牌明文只在创建时返回一次",
+		Body: models.TokenCreateInput{}, Status: http.StatusCreated, Data: models.PersonalAccessTokenResponse{},
+	},
+	openapi.Key(http.MethodDelete, "/api/me/tokens/:id"): {
+		Tag: "访问令牌", Summary: "撤销个人访问令牌",
+	},
+
+	// 管理员
+	openapi.Key(http.MethodGet, "/api/admin/users"): {
+		Tag: "管理员", Summary: "获取用户列表",
+		Query: paged(
+			openapi.Param{Name: "q", Description: "按用户名或邮箱搜索"},
+			openapi.Param{Name: "role", Description: "user、moderator或admin"},
+			openapi.Param{Name: "status", Description: "active或suspended"},
+		),
+		Data: []models.ManagedUser{},
+	},
+	openapi.Key(http.MethodPost, "/api/admin/users/:id/suspend"): {
+		Tag: "管理员", Summary: "停用用户",
+		Body: models.SuspendInput{}, Data: models.ManagedUser{},
+	},
+	openapi.Key(http.MethodPost, "/api/admin/users/:id/unsuspend"): {
+		Tag: "管理员", Summary: "恢复被停用的用户", Data: models.ManagedUser{},
+	},
+	openapi.Key(http.MethodPost, "/api/admin/users/:id/logout"): {
+		Tag: "管理员", Summary: "强制下线", Description: "此前签发的JWT全部失效，个人访问令牌全部撤销",
+		Data: revokedTokens{},
+	},
+	openapi.Key(http.MethodDelete, "/api/admin/users/:id"): {
+		Tag: "管理员", Summary: "删除用户",
+		Query: []openapi.Param{
+			{Name: "content", Required: true, Description: "reassign：文章和评论转给reassign_to；delete：文章移入回收站，评论删除"},
+			{Name: "reassign_to", Type: "integer", Description: "content为reassign时接收内容的用户"},
+		},
+		Data: deletedContent{},
+	},
+	openapi.Key(http.MethodGet, "/api/admin/stats"): {
+		Tag: "管理员", Summary: "站点统计",
+		Query: []openapi.Param{{Name: "days", Type: "integer", Description: "统计最近几天每天新增的数量，默认30，最多365"}},
+		Data:  models.SiteStats{},
+	},
+	openapi.Key(http.MethodGet, "/api/admin/audit"): {
+		Tag: "审计日志", Summary: "获取审计日志，最新的在前",
+		Query: paged(openapi.FormParams(models.AuditQuery{})...), Data: []models.AuditEvent{},
+	},
+	openapi.Key(http.MethodGet, "/api/admin/audit/export"): {
+		Tag: "审计日志", Summary: "导出审计日志（JSON Lines），最早的在前",
+		Query: openapi.FormParams(models.AuditQuery{}), Content: "application/x-ndjson",
+	},
+}
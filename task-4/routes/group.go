@@ -0,0 +1,96 @@
+package routes
+
+import (
+	"net/http"
+	"path"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xhy/blog-api/middleware"
+	"github.com/xhy/blog-api/models"
+	"github.com/xhy/blog-api/openapi"
+)
+
+// security 路由的认证方式、角色和权限范围
+type security struct {
+	auth  openapi.Auth
+	role  string
+	scope string
+}
+
+// routeGroup 按声明的认证方式、角色和权限范围加上对应的中间件，并把声明登记到table中，
+// 接口文档中的这几项由登记的内容生成，与实际使用的中间件保持一致
+type routeGroup struct {
+	group *gin.RouterGroup
+	security
+	table map[string]security
+}
+
+// newRouteGroup 创建路由组，组内所有路由使用相同的认证方式，role不为空时要求对应的角色（目前只支持管理员）
+func newRouteGroup(router *gin.Engine, table map[string]security, relativePath string, auth openapi.Auth, role string) routeGroup {
+	group := router.Group(relativePath)
+	switch auth {
+	case openapi.AuthOptional:
+		group.Use(middleware.OptionalAuthMiddleware())
+	case openapi.AuthRequired:
+		group.Use(middleware.AuthMiddleware())
+	case openapi.AuthQuery:
+		group.Use(middleware.QueryTokenMiddleware(), middleware.AuthMiddleware())
+	}
+	return routeGroup{group: group, security: security{auth: auth, role: role}, table: table}
+}
+
+// scoped 返回要求指定权限范围的路由组，在其上注册的路由先经过RequireScope
+func (g routeGroup) scoped(scope string) routeGroup {
+	g.scope = scope
+	return g
+}
+
+// GET 注册GET路由
+func (g routeGroup) GET(relativePath string, handler gin.HandlerFunc) {
+	g.handle(http.MethodGet, relativePath, handler)
+}
+
+// POST 注册POST路由
+func (g routeGroup) POST(relativePath string, handler gin.HandlerFunc) {
+	g.handle(http.MethodPost, relativePath, handler)
+}
+
+// PUT 注册PUT路由
+func (g routeGroup) PUT(relativePath string, handler gin.HandlerFunc) {
+	g.handle(http.MethodPut, relativePath, handler)
+}
+
+// DELETE 注册DELETE路由
+func (g routeGroup) DELETE(relativePath string, handler gin.HandlerFunc) {
+	g.handle(http.MethodDelete, relativePath, handler)
+}
+
+// handle 注册路由并登记认证方式、角色和权限范围，先检查权限范围再检查角色
+func (g routeGroup) handle(method, relativePath string, handler gin.HandlerFunc) {
+	var handlers []gin.HandlerFunc
+	if g.scope != "" {
+		handlers = append(handlers, middleware.RequireScope(g.scope))
+	}
+	if g.role == models.RoleAdmin {
+		handlers = append(handlers, middleware.RequireAdmin())
+	}
+	handlers = append(handlers, handler)
+	g.group.Handle(method, relativePath, handlers...)
+	g.table[openapi.Key(method, path.Join(g.group.BasePath(), relativePath))] = g.security
+}
+
+// withSecurity 在接口说明中填入注册路由时登记的认证方式、角色和权限范围
+func withSecurity(docs map[string]openapi.Route, table map[string]security) map[string]openapi.Route {
+	merged := make(map[string]openapi.Route, len(docs))
+	for key, route := range docs {
+		if sec, ok := table[key]; ok {
+			route.Auth = sec.auth
+			route.Scope = sec.scope
+			if sec.role != "" {
+				route.Role = sec.role
+			}
+		}
+		merged[key] = route
+	}
+	return merged
+}
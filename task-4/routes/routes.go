@@ -3,16 +3,40 @@ package routes
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/xhy/blog-api/controllers"
+	"github.com/xhy/blog-api/graph"
 	"github.com/xhy/blog-api/middleware"
+	"github.com/xhy/blog-api/models"
+	"github.com/xhy/blog-api/openapi"
 )
 
 // SetupRoutes 配置路由
 func SetupRoutes(router *gin.Engine) {
+	setupRoutes(router)
+}
+
+// setupRoutes 配置路由，返回填入了认证方式、角色和权限范围的接口说明
+func setupRoutes(router *gin.Engine) map[string]openapi.Route {
+	// 注册路由时登记的认证方式、角色和权限范围
+	table := make(map[string]security)
+
 	// 中间件
-	router.Use(middleware.LoggerMiddleware())
+	router.Use(middleware.RequestIDMiddleware(), middleware.LoggerMiddleware())
+
+	// JWT验证公钥
+	router.GET("/.well-known/jwks.json", controllers.GetJWKS)
+
+	// 订阅源
+	router.GET("/feed.xml", controllers.GetRSSFeed)
+	router.GET("/atom.xml", controllers.GetAtomFeed)
+	router.GET("/users/:id/feed.xml", controllers.GetUserRSSFeed)
+
+	// 搜索引擎
+	router.GET("/sitemap.xml", controllers.GetSitemap)
+	router.GET("/sitemaps/:file", controllers.GetSitemapPage)
+	router.GET("/robots.txt", controllers.GetRobots)
 
 	// 公开路由
-	public := router.Group("/api")
+	public := newRouteGroup(router, table, "/api", openapi.AuthOptional, "")
 	{
 		// 用户认证
 		public.POST("/register", controllers.Register)
@@ -21,19 +45,111 @@ func SetupRoutes(router *gin.Engine) {
 		// 文章相关
 		public.GET("/posts", controllers.GetPosts)
 		public.GET("/posts/:id", controllers.GetPost)
+		public.GET("/posts/by-slug/:slug", controllers.GetPostBySlug)
 		public.GET("/posts/:id/comments", controllers.GetComments)
+
+		// 用户相关
+		public.GET("/users/:id", controllers.GetUserProfile)
+		public.GET("/users/:id/followers", controllers.GetFollowers)
+		public.GET("/users/:id/following", controllers.GetFollowing)
+
+		// 附件相关
+		public.GET("/posts/:id/attachments", controllers.GetAttachments)
+		public.GET("/attachments/:id", controllers.ServeAttachment)
+		public.GET("/attachments/:id/thumbnail", controllers.ServeThumbnail)
 	}
 
-	// 需要认证的路由
-	protected := router.Group("/api")
-	protected.Use(middleware.AuthMiddleware())
+	// 需要认证的路由，每个路由声明所需的权限范围
+	protected := newRouteGroup(router, table, "/api", openapi.AuthRequired, "")
 	{
 		// 文章相关
-		protected.POST("/posts", controllers.CreatePost)
-		protected.PUT("/posts/:id", controllers.UpdatePost)
-		protected.DELETE("/posts/:id", controllers.DeletePost)
+		protected.scoped(models.ScopePostsWrite).POST("/posts", controllers.CreatePost)
+		protected.scoped(models.ScopePostsWrite).PUT("/posts/:id", controllers.UpdatePost)
+		protected.scoped(models.ScopePostsWrite).DELETE("/posts/:id", controllers.DeletePost)
+		protected.scoped(models.ScopePostsWrite).POST("/posts/:id/restore", controllers.RestorePost)
 
 		// 评论相关
-		protected.POST("/posts/:id/comments", controllers.CreateComment)
+		protected.scoped(models.ScopeCommentsWrite).POST("/posts/:id/comments", controllers.CreateComment)
+
+		// 评论审核
+		protected.scoped(models.ScopeCommentsWrite).GET("/moderation/comments", controllers.GetModerationQueue)
+		protected.scoped(models.ScopeCommentsWrite).POST("/moderation/comments", controllers.ModerateComments)
+
+		// 举报相关
+		protected.scoped(models.ScopeReportsWrite).POST("/posts/:id/report", controllers.ReportPost)
+		protected.scoped(models.ScopeReportsWrite).POST("/comments/:id/report", controllers.ReportComment)
+		protected.scoped(models.ScopeReportsWrite).GET("/moderation/reports", controllers.GetReports)
+		protected.scoped(models.ScopeReportsWrite).POST("/moderation/reports/:id/resolve", controllers.ResolveReport)
+		protected.scoped(models.ScopeReportsWrite).POST("/moderation/reports/:id/dismiss", controllers.DismissReport)
+
+		// 表态相关
+		protected.scoped(models.ScopeReactionsWrite).POST("/posts/:id/reactions", controllers.AddPostReaction)
+		protected.scoped(models.ScopeReactionsWrite).DELETE("/posts/:id/reactions/:emoji", controllers.RemovePostReaction)
+		protected.scoped(models.ScopeReactionsWrite).POST("/comments/:id/reactions", controllers.AddCommentReaction)
+		protected.scoped(models.ScopeReactionsWrite).DELETE("/comments/:id/reactions/:emoji", controllers.RemoveCommentReaction)
+
+		// 关注相关
+		protected.scoped(models.ScopeFollowsWrite).POST("/users/:id/follow", controllers.FollowUser)
+		protected.scoped(models.ScopeFollowsWrite).DELETE("/users/:id/follow", controllers.UnfollowUser)
+		protected.scoped(models.ScopeFeedRead).GET("/feed", controllers.GetFeed)
+
+		// 通知相关
+		protected.scoped(models.ScopeNotifications).GET("/notifications", controllers.GetNotifications)
+		protected.scoped(models.ScopeNotifications).POST("/notifications/:id/read", controllers.MarkNotificationRead)
+		protected.scoped(models.ScopeNotifications).POST("/notifications/read-all", controllers.MarkAllNotificationsRead)
+
+		// 收藏相关
+		protected.scoped(models.ScopeBookmarksWrite).POST("/posts/:id/bookmark", controllers.AddBookmark)
+		protected.scoped(models.ScopeBookmarksWrite).DELETE("/posts/:id/bookmark", controllers.RemoveBookmark)
+		protected.scoped(models.ScopeBookmarksWrite).GET("/me/bookmarks", controllers.GetBookmarks)
+		protected.scoped(models.ScopeBookmarksWrite).GET("/me/collections", controllers.GetCollections)
+		protected.scoped(models.ScopeBookmarksWrite).POST("/me/collections", controllers.CreateCollection)
+		protected.scoped(models.ScopeBookmarksWrite).DELETE("/me/collections/:id", controllers.DeleteCollection)
+
+		// 回收站
+		protected.scoped(models.ScopePostsWrite).GET("/me/trash", controllers.GetTrash)
+		protected.scoped(models.ScopePostsWrite).DELETE("/me/trash/:id", controllers.PurgePost)
+
+		// 附件相关
+		protected.scoped(models.ScopePostsWrite).POST("/posts/:id/attachments", controllers.UploadAttachment)
+		protected.scoped(models.ScopePostsWrite).DELETE("/attachments/:id", controllers.DeleteAttachment)
+
+		// 个人访问令牌
+		protected.scoped(models.ScopeTokensManage).GET("/me/tokens", controllers.GetTokens)
+		protected.scoped(models.ScopeTokensManage).POST("/me/tokens", controllers.CreateToken)
+		protected.scoped(models.ScopeTokensManage).DELETE("/me/tokens/:id", controllers.RevokeToken)
 	}
-} 
\ No newline at end of file
+
+	// 管理员路由，要求管理员角色，且只接受登录会话，访问令牌没有admin权限范围
+	admin := newRouteGroup(router, table, "/api/admin", openapi.AuthRequired, models.RoleAdmin).scoped(models.ScopeAdmin)
+	{
+		// 用户管理
+		admin.GET("/users", controllers.GetUsers)
+		admin.POST("/users/:id/suspend", controllers.SuspendUser)
+		admin.POST("/users/:id/unsuspend", controllers.UnsuspendUser)
+		admin.POST("/users/:id/logout", controllers.LogoutUser)
+		admin.DELETE("/users/:id", controllers.DeleteUser)
+
+		// 站点统计
+		admin.GET("/stats", controllers.GetSiteStats)
+
+		// 审计日志
+		admin.GET("/audit", controllers.GetAuditLog)
+		admin.GET("/audit/export", controllers.ExportAuditLog)
+	}
+
+	// GraphQL接口，变更转发给上面的REST路由处理
+	newRouteGroup(router, table, "/", openapi.AuthOptional, "").POST("/graphql", graph.Handler(router))
+
+	// 实时推送，EventSource无法设置请求头，允许通过查询参数传递令牌
+	stream := newRouteGroup(router, table, "/api", openapi.AuthQuery, "")
+	{
+		stream.scoped(models.ScopeNotifications).GET("/notifications/stream", controllers.StreamNotifications)
+	}
+
+	// 接口文档，根据路由表、apiDocs和注册路由时登记的认证方式、角色和权限范围生成
+	docs := withSecurity(apiDocs, table)
+	router.GET("/openapi.json", openapi.Handler(router, docs))
+	router.GET("/docs/*filepath", openapi.SwaggerUI("/openapi.json"))
+	return docs
+}
@@ -0,0 +1,100 @@
+package routes
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xhy/blog-api/models"
+	"github.com/xhy/blog-api/openapi"
+)
+
+// pathParam gin的路径参数，对应OpenAPI路径中的{name}
+var pathParam = regexp.MustCompile(`[:*]([^/]+)`)
+
+// TestOpenAPICoversRoutes 每个路由都必须出现在OpenAPI文档中并登记接口说明
+func TestOpenAPICoversRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	docs := setupRoutes(router)
+
+	routes := router.Routes()
+	doc, problems := openapi.Build(openapi.Info{Title: "test", Version: "test"}, nil, routes, docs)
+	for _, problem := range problems {
+		t.Error(problem)
+	}
+
+	for _, route := range routes {
+		path := pathParam.ReplaceAllString(route.Path, "{$1}")
+		op := doc.Paths[path][strings.ToLower(route.Method)]
+		if op == nil {
+			t.Errorf("OpenAPI文档缺少路由 %s %s", route.Method, route.Path)
+			continue
+		}
+		if op.Summary == "" {
+			t.Errorf("路由 %s %s 没有摘要", route.Method, route.Path)
+		}
+	}
+}
+
+// TestOpenAPIAuth 需要认证的路由在文档中声明认证方式和权限范围
+func TestOpenAPIAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	docs := setupRoutes(router)
+
+	doc, _ := openapi.Build(openapi.Info{Title: "test", Version: "test"}, nil, router.Routes(), docs)
+	op := doc.Paths["/api/posts"]["post"]
+	if op == nil {
+		t.Fatal("OpenAPI文档缺少创建文章接口")
+	}
+	if len(op.Security) != 1 || op.Security[0]["bearerAuth"] == nil {
+		t.Errorf("创建文章接口的认证方式为 %v，期望bearerAuth", op.Security)
+	}
+	if op.Scope != "posts:write" {
+		t.Errorf("创建文章接口的权限范围为 %q，期望posts:write", op.Scope)
+	}
+	if op.Responses["201"] == nil || op.Responses["401"] == nil {
+		t.Errorf("创建文章接口缺少201或401响应: %v", op.Responses)
+	}
+	if _, ok := doc.Components.Schemas["Response"]; !ok {
+		t.Error("OpenAPI文档缺少通用响应结构Response")
+	}
+	if _, ok := doc.Paths["/api/posts"][strings.ToLower(http.MethodGet)]; !ok {
+		t.Error("OpenAPI文档缺少文章列表接口")
+	}
+}
+
+// TestSecurityDeclaredAtRegistration 认证方式和权限范围只在注册路由时声明，
+// 需要认证的路由都要求权限范围，文档中的内容与注册时使用的中间件一致
+func TestSecurityDeclaredAtRegistration(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	table := make(map[string]security)
+	// 与setupRoutes相同的方式登记一个路由，确认handle登记的内容
+	newRouteGroup(router, table, "/api", openapi.AuthRequired, "").scoped("test:write").POST("/things/:id", func(c *gin.Context) {})
+	if sec := table[openapi.Key(http.MethodPost, "/api/things/:id")]; sec.auth != openapi.AuthRequired || sec.scope != "test:write" {
+		t.Errorf("登记的认证方式和权限范围为 %+v，期望AuthRequired和test:write", sec)
+	}
+
+	for key, route := range apiDocs {
+		if route.Auth != openapi.AuthNone || route.Scope != "" {
+			t.Errorf("%s 在apiDocs中声明了认证方式或权限范围，应在注册路由时声明", key)
+		}
+	}
+
+	router = gin.New()
+	docs := setupRoutes(router)
+	for _, route := range router.Routes() {
+		key := openapi.Key(route.Method, route.Path)
+		doc := docs[key]
+		if (doc.Auth == openapi.AuthRequired || doc.Auth == openapi.AuthQuery) && doc.Scope == "" {
+			t.Errorf("需要认证的路由 %s 没有声明权限范围", key)
+		}
+		if strings.HasPrefix(route.Path, "/api/admin/") && (doc.Role != models.RoleAdmin || doc.Scope != models.ScopeAdmin) {
+			t.Errorf("管理员路由 %s 的角色为 %q、权限范围为 %q", key, doc.Role, doc.Scope)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package rpc
+
+import (
+	"context"
+	"strings"
+
+	"github.com/xhy/blog-api/middleware"
+	"github.com/xhy/blog-api/rpc/blogpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// 博客服务方法名的前缀，健康检查和服务反射不需要认证
+const blogMethodPrefix = "/blog.v1."
+
+// publicMethods 允许匿名调用的方法，与REST接口中使用OptionalAuthMiddleware的公开路由对应
+var publicMethods = map[string]bool{
+	blogpb.UserService_Register_FullMethodName:        true,
+	blogpb.UserService_Login_FullMethodName:           true,
+	blogpb.UserService_GetUser_FullMethodName:         true,
+	blogpb.UserService_ListFollowers_FullMethodName:   true,
+	blogpb.UserService_ListFollowing_FullMethodName:   true,
+	blogpb.PostService_ListPosts_FullMethodName:       true,
+	blogpb.PostService_GetPost_FullMethodName:         true,
+	blogpb.PostService_GetPostBySlug_FullMethodName:   true,
+	blogpb.CommentService_ListComments_FullMethodName: true,
+}
+
+// tokenKey 在上下文中保存已通过认证的令牌的键，令牌在转发给REST接口时沿用
+type tokenKey struct{}
+
+// tokenFrom 返回已通过认证的令牌，匿名调用时为空
+func tokenFrom(ctx context.Context) string {
+	token, _ := ctx.Value(tokenKey{}).(string)
+	return token
+}
+
+// UnaryAuthInterceptor 一元调用的认证拦截器
+func UnaryAuthInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := authenticate(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// StreamAuthInterceptor 流式调用的认证拦截器
+func StreamAuthInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := authenticate(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+}
+
+// authenticatedStream 携带调用方信息的流
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context 返回携带调用方信息的上下文
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+// authenticate 使用与REST接口相同的规则校验metadata中的 authorization: Bearer <token>，支持JWT和个人访问令牌
+// 公开方法与OptionalAuthMiddleware一致，没有凭证或凭证无效时按匿名用户处理
+func authenticate(ctx context.Context, fullMethod string) (context.Context, error) {
+	if !strings.HasPrefix(fullMethod, blogMethodPrefix) {
+		return ctx, nil
+	}
+	public := publicMethods[fullMethod]
+
+	var header string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("authorization"); len(values) > 0 {
+			header = values[0]
+		}
+	}
+	if header == "" {
+		if public {
+			return ctx, nil
+		}
+		return nil, status.Error(codes.Unauthenticated, "未提供认证令牌")
+	}
+
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || scheme != "Bearer" {
+		if public {
+			return ctx, nil
+		}
+		return nil, status.Error(codes.Unauthenticated, "认证格式错误")
+	}
+
+	if identity, httpStatus, message := middleware.Authenticate(token); identity == nil {
+		if public {
+			return ctx, nil
+		}
+		return nil, status.Error(grpcCode(httpStatus), message)
+	}
+	return context.WithValue(ctx, tokenKey{}, token), nil
+}
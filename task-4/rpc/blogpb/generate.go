@@ -0,0 +1,4 @@
+package blogpb
+
+// 修改blog.proto后在项目根目录执行 go generate ./rpc/... 重新生成代码，需要安装protoc、protoc-gen-go和protoc-gen-go-grpc
+//go:generate protoc -I ../.. --go_out=../.. --go_opt=paths=source_relative --go-grpc_out=../.. --go-grpc_opt=paths=source_relative rpc/blogpb/blog.proto
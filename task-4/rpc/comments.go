@@ -0,0 +1,69 @@
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/xhy/blog-api/models"
+	"github.com/xhy/blog-api/rpc/blogpb"
+)
+
+// commentService 评论服务，对应 /api/posts/:id/comments 和 /api/moderation/comments 接口
+type commentService struct {
+	blogpb.UnimplementedCommentServiceServer
+	*backend
+}
+
+// ListComments 获取文章的评论，包括当前用户自己等待审核的评论
+func (s *commentService) ListComments(ctx context.Context, req *blogpb.ListCommentsRequest) (*blogpb.ListCommentsResponse, error) {
+	var comments []models.Comment
+	if err := s.call(ctx, http.MethodGet, "/api/posts/"+pathID(req.PostId)+"/comments", nil, &comments); err != nil {
+		return nil, err
+	}
+	return &blogpb.ListCommentsResponse{Comments: toComments(comments)}, nil
+}
+
+// CreateComment 发表评论
+func (s *commentService) CreateComment(ctx context.Context, req *blogpb.CreateCommentRequest) (*blogpb.Comment, error) {
+	input := models.CommentInput{Content: req.Content}
+	if req.ParentId != nil {
+		parentID := uint(*req.ParentId)
+		input.ParentID = &parentID
+	}
+	var comment models.Comment
+	if err := s.call(ctx, http.MethodPost, "/api/posts/"+pathID(req.PostId)+"/comments", input, &comment); err != nil {
+		return nil, err
+	}
+	return toComment(&comment), nil
+}
+
+// ListModerationQueue 获取评论审核队列，版主可以看到所有文章的评论，作者只能看到自己文章的评论
+func (s *commentService) ListModerationQueue(ctx context.Context, req *blogpb.ListModerationQueueRequest) (*blogpb.ListCommentsResponse, error) {
+	query := pageQuery(req.Page, req.PageSize)
+	if req.Status != "" {
+		query.Set("status", req.Status)
+	}
+	if req.PostId != 0 {
+		query.Set("post_id", strconv.FormatUint(req.PostId, 10))
+	}
+
+	var comments []models.Comment
+	if err := s.call(ctx, http.MethodGet, withQuery("/api/moderation/comments", query), nil, &comments); err != nil {
+		return nil, err
+	}
+	return &blogpb.ListCommentsResponse{Comments: toComments(comments)}, nil
+}
+
+// ModerateComments 批量审核评论
+func (s *commentService) ModerateComments(ctx context.Context, req *blogpb.ModerateCommentsRequest) (*blogpb.ModerateCommentsResponse, error) {
+	input := models.ModerationInput{Action: req.Action}
+	for _, id := range req.Ids {
+		input.IDs = append(input.IDs, uint(id))
+	}
+	var result models.ModerationResult
+	if err := s.call(ctx, http.MethodPost, "/api/moderation/comments", input, &result); err != nil {
+		return nil, err
+	}
+	return &blogpb.ModerateCommentsResponse{Updated: toIDs(result.Updated), Skipped: toIDs(result.Skipped)}, nil
+}
@@ -0,0 +1,130 @@
+package rpc
+
+import (
+	"time"
+
+	"github.com/xhy/blog-api/models"
+	"github.com/xhy/blog-api/rpc/blogpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// timestamp 转换时间，零值为nil
+func timestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+// toUserBrief 转换用户简要信息，没有加载关联用户时为nil
+func toUserBrief(u *models.User) *blogpb.UserBrief {
+	if u.ID == 0 {
+		return nil
+	}
+	return &blogpb.UserBrief{Id: uint64(u.ID), Username: u.Username}
+}
+
+// toUserBriefs 转换粉丝、关注列表
+func toUserBriefs(users []models.UserBrief) []*blogpb.UserBrief {
+	results := make([]*blogpb.UserBrief, len(users))
+	for i, u := range users {
+		results[i] = &blogpb.UserBrief{Id: uint64(u.ID), Username: u.Username}
+	}
+	return results
+}
+
+// toUserProfile 转换用户主页信息
+func toUserProfile(p *models.UserProfile) *blogpb.UserProfile {
+	return &blogpb.UserProfile{
+		Id:             uint64(p.ID),
+		Username:       p.Username,
+		CreatedAt:      timestamp(p.CreatedAt),
+		PostCount:      p.PostCount,
+		FollowerCount:  p.FollowerCount,
+		FollowingCount: p.FollowingCount,
+		Following:      p.Following,
+	}
+}
+
+// toReactions 转换表态统计
+func toReactions(summaries []models.ReactionSummary) []*blogpb.ReactionSummary {
+	results := make([]*blogpb.ReactionSummary, len(summaries))
+	for i, s := range summaries {
+		results[i] = &blogpb.ReactionSummary{Emoji: s.Emoji, Symbol: s.Symbol, Count: s.Count, Reacted: s.Reacted}
+	}
+	return results
+}
+
+// toPost 转换文章
+func toPost(p *models.Post) *blogpb.Post {
+	post := &blogpb.Post{
+		Id:           uint64(p.ID),
+		Title:        p.Title,
+		Slug:         p.Slug,
+		Content:      p.Content,
+		Format:       p.Format,
+		ContentHtml:  p.ContentHTML,
+		UserId:       uint64(p.UserID),
+		Author:       toUserBrief(&p.User),
+		CommentCount: p.CommentCount,
+		ViewCount:    p.ViewCount,
+		Moderation:   p.Moderation,
+		Hidden:       p.Hidden,
+		Reactions:    toReactions(p.Reactions),
+		Comments:     toComments(p.Comments),
+		CreatedAt:    timestamp(p.CreatedAt),
+		UpdatedAt:    timestamp(p.UpdatedAt),
+	}
+	for _, h := range p.TOC {
+		post.Toc = append(post.Toc, &blogpb.Heading{Level: int32(h.Level), Text: h.Text, Id: h.ID})
+	}
+	return post
+}
+
+// toPosts 转换文章列表
+func toPosts(posts []models.Post) []*blogpb.Post {
+	results := make([]*blogpb.Post, len(posts))
+	for i := range posts {
+		results[i] = toPost(&posts[i])
+	}
+	return results
+}
+
+// toComment 转换评论
+func toComment(c *models.Comment) *blogpb.Comment {
+	comment := &blogpb.Comment{
+		Id:           uint64(c.ID),
+		PostId:       uint64(c.PostID),
+		UserId:       uint64(c.UserID),
+		Author:       toUserBrief(&c.User),
+		Content:      c.Content,
+		ContentHtml:  c.ContentHTML,
+		Status:       c.Status,
+		FilterReason: c.FilterReason,
+		Reactions:    toReactions(c.Reactions),
+		CreatedAt:    timestamp(c.CreatedAt),
+	}
+	if c.ParentID != nil {
+		parentID := uint64(*c.ParentID)
+		comment.ParentId = &parentID
+	}
+	return comment
+}
+
+// toComments 转换评论列表
+func toComments(comments []models.Comment) []*blogpb.Comment {
+	results := make([]*blogpb.Comment, len(comments))
+	for i := range comments {
+		results[i] = toComment(&comments[i])
+	}
+	return results
+}
+
+// toIDs 转换ID列表
+func toIDs(ids []uint) []uint64 {
+	results := make([]uint64, len(ids))
+	for i, id := range ids {
+		results[i] = uint64(id)
+	}
+	return results
+}
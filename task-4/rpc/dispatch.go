@@ -0,0 +1,109 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/xhy/blog-api/dispatch"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// 转发给REST接口时沿用的metadata：请求ID
+// 客户端IP取连接的对端地址，不转发调用方自行设置的X-Forwarded-For、X-Real-IP
+var forwardedMetadata = []string{"X-Request-ID"}
+
+// backend 把gRPC调用交给对应的REST路由处理，业务规则、权限检查、审计日志和缓存失效与REST请求一致
+type backend struct {
+	engine http.Handler
+}
+
+// call 调用REST接口，成功时把响应中的data解码到out（可以为nil），失败时返回对应状态码的gRPC错误
+func (b *backend) call(ctx context.Context, method, path string, body interface{}, out interface{}) error {
+	header := make(http.Header)
+	if token := tokenFrom(ctx); token != "" {
+		header.Set("Authorization", "Bearer "+token)
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for _, name := range forwardedMetadata {
+			if values := md.Get(name); len(values) > 0 {
+				header.Set(name, values[0])
+			}
+		}
+	}
+
+	var remoteAddr string
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		remoteAddr = p.Addr.String()
+	}
+
+	err := dispatch.Do(ctx, b.engine, dispatch.Request{
+		Method:     method,
+		Path:       path,
+		Header:     header,
+		RemoteAddr: remoteAddr,
+		Body:       body,
+	}, out)
+
+	var restErr *dispatch.Error
+	if errors.As(err, &restErr) {
+		return status.Error(grpcCode(restErr.Status), restErr.Message)
+	}
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	return nil
+}
+
+// grpcCode 把REST接口的HTTP状态码转换为gRPC状态码
+func grpcCode(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.AlreadyExists
+	case http.StatusPreconditionFailed:
+		return codes.FailedPrecondition
+	case http.StatusRequestEntityTooLarge, http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	}
+	return codes.Internal
+}
+
+// pathID 拼接路径中的ID
+func pathID(id uint64) string {
+	return strconv.FormatUint(id, 10)
+}
+
+// pageQuery 分页参数，为0时使用REST接口的默认值
+func pageQuery(page, pageSize int32) url.Values {
+	query := url.Values{}
+	if page > 0 {
+		query.Set("page", strconv.Itoa(int(page)))
+	}
+	if pageSize > 0 {
+		query.Set("pageSize", strconv.Itoa(int(pageSize)))
+	}
+	return query
+}
+
+// withQuery 把查询参数拼接到路径上
+func withQuery(path string, query url.Values) string {
+	if len(query) == 0 {
+		return path
+	}
+	return path + "?" + query.Encode()
+}
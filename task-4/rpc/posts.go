@@ -0,0 +1,98 @@
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/xhy/blog-api/models"
+	"github.com/xhy/blog-api/rpc/blogpb"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// postService 文章服务，对应 /api/posts 接口
+type postService struct {
+	blogpb.UnimplementedPostServiceServer
+	*backend
+}
+
+// ListPosts 获取文章列表，最新的在前
+func (s *postService) ListPosts(ctx context.Context, req *blogpb.ListPostsRequest) (*blogpb.ListPostsResponse, error) {
+	var posts []models.Post
+	if err := s.call(ctx, http.MethodGet, withQuery("/api/posts", pageQuery(req.Page, req.PageSize)), nil, &posts); err != nil {
+		return nil, err
+	}
+	return &blogpb.ListPostsResponse{Posts: toPosts(posts)}, nil
+}
+
+// GetPost 获取文章及评论
+func (s *postService) GetPost(ctx context.Context, req *blogpb.GetPostRequest) (*blogpb.Post, error) {
+	return s.getPost(ctx, "/api/posts/"+pathID(req.Id), req.RenderHtml)
+}
+
+// GetPostBySlug 按别名获取文章及评论
+func (s *postService) GetPostBySlug(ctx context.Context, req *blogpb.GetPostBySlugRequest) (*blogpb.Post, error) {
+	return s.getPost(ctx, "/api/posts/by-slug/"+url.PathEscape(req.Slug), req.RenderHtml)
+}
+
+// getPost 获取文章，renderHTML为true时同时返回过滤后的HTML和目录
+func (s *postService) getPost(ctx context.Context, path string, renderHTML bool) (*blogpb.Post, error) {
+	query := url.Values{}
+	if renderHTML {
+		query.Set("render", "html")
+	}
+	var post models.Post
+	if err := s.call(ctx, http.MethodGet, withQuery(path, query), nil, &post); err != nil {
+		return nil, err
+	}
+	return toPost(&post), nil
+}
+
+// CreatePost 创建文章
+func (s *postService) CreatePost(ctx context.Context, req *blogpb.CreatePostRequest) (*blogpb.Post, error) {
+	input := models.PostInput{
+		Title:      req.Title,
+		Content:    req.Content,
+		Format:     req.Format,
+		Slug:       req.Slug,
+		Moderation: req.Moderation,
+	}
+	var post models.Post
+	if err := s.call(ctx, http.MethodPost, "/api/posts", input, &post); err != nil {
+		return nil, err
+	}
+	return toPost(&post), nil
+}
+
+// UpdatePost 更新文章，只有作者可以修改
+func (s *postService) UpdatePost(ctx context.Context, req *blogpb.UpdatePostRequest) (*blogpb.Post, error) {
+	input := models.PostInput{
+		Title:      req.Title,
+		Content:    req.Content,
+		Format:     req.Format,
+		Slug:       req.Slug,
+		Moderation: req.Moderation,
+	}
+	var post models.Post
+	if err := s.call(ctx, http.MethodPut, "/api/posts/"+pathID(req.Id), input, &post); err != nil {
+		return nil, err
+	}
+	return toPost(&post), nil
+}
+
+// DeletePost 将文章移入回收站
+func (s *postService) DeletePost(ctx context.Context, req *blogpb.DeletePostRequest) (*emptypb.Empty, error) {
+	if err := s.call(ctx, http.MethodDelete, "/api/posts/"+pathID(req.Id), nil, nil); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// RestorePost 从回收站恢复文章
+func (s *postService) RestorePost(ctx context.Context, req *blogpb.RestorePostRequest) (*blogpb.Post, error) {
+	var post models.Post
+	if err := s.call(ctx, http.MethodPost, "/api/posts/"+pathID(req.Id)+"/restore", nil, &post); err != nil {
+		return nil, err
+	}
+	return toPost(&post), nil
+}
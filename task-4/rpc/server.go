@@ -0,0 +1,70 @@
+package rpc
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/xhy/blog-api/config"
+	"github.com/xhy/blog-api/rpc/blogpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+// Server 与REST服务器并行运行的gRPC服务器
+type Server struct {
+	grpc   *grpc.Server
+	health *health.Server
+}
+
+// NewServer 创建gRPC服务器，调用交给engine中对应的REST路由处理
+func NewServer(engine http.Handler, cfg config.GRPCConfig) *Server {
+	s := &Server{
+		grpc: grpc.NewServer(
+			grpc.ChainUnaryInterceptor(UnaryAuthInterceptor),
+			grpc.ChainStreamInterceptor(StreamAuthInterceptor),
+		),
+		health: health.NewServer(),
+	}
+
+	b := &backend{engine: engine}
+	blogpb.RegisterUserServiceServer(s.grpc, &userService{backend: b})
+	blogpb.RegisterPostServiceServer(s.grpc, &postService{backend: b})
+	blogpb.RegisterCommentServiceServer(s.grpc, &commentService{backend: b})
+
+	// 健康检查：空服务名表示整个服务器
+	healthpb.RegisterHealthServer(s.grpc, s.health)
+	for name := range s.grpc.GetServiceInfo() {
+		s.health.SetServingStatus(name, healthpb.HealthCheckResponse_SERVING)
+	}
+	s.health.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+
+	// 服务反射，便于用grpcurl等工具调试
+	if cfg.Reflection {
+		reflection.Register(s.grpc)
+	}
+	return s
+}
+
+// Serve 在lis上接收连接，直到服务器停止
+func (s *Server) Serve(lis net.Listener) error {
+	return s.grpc.Serve(lis)
+}
+
+// Stop 将健康状态置为NOT_SERVING后等待处理中的调用完成，超过timeout时强制关闭
+func (s *Server) Stop(timeout time.Duration) {
+	s.health.Shutdown()
+
+	done := make(chan struct{})
+	go func() {
+		s.grpc.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		s.grpc.Stop()
+	}
+}
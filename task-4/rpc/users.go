@@ -0,0 +1,80 @@
+package rpc
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/xhy/blog-api/models"
+	"github.com/xhy/blog-api/rpc/blogpb"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// userService 用户服务，对应 /api/register、/api/login 和 /api/users 接口
+type userService struct {
+	blogpb.UnimplementedUserServiceServer
+	*backend
+}
+
+// Register 用户注册
+func (s *userService) Register(ctx context.Context, req *blogpb.RegisterRequest) (*emptypb.Empty, error) {
+	input := models.UserRegisterInput{Username: req.Username, Password: req.Password, Email: req.Email}
+	if err := s.call(ctx, http.MethodPost, "/api/register", input, nil); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// Login 用户登录
+func (s *userService) Login(ctx context.Context, req *blogpb.LoginRequest) (*blogpb.LoginResponse, error) {
+	var token models.TokenResponse
+	input := models.UserLoginInput{Username: req.Username, Password: req.Password}
+	if err := s.call(ctx, http.MethodPost, "/api/login", input, &token); err != nil {
+		return nil, err
+	}
+	return &blogpb.LoginResponse{Token: token.Token}, nil
+}
+
+// GetUser 获取用户主页信息
+func (s *userService) GetUser(ctx context.Context, req *blogpb.GetUserRequest) (*blogpb.UserProfile, error) {
+	var profile models.UserProfile
+	if err := s.call(ctx, http.MethodGet, "/api/users/"+pathID(req.Id), nil, &profile); err != nil {
+		return nil, err
+	}
+	return toUserProfile(&profile), nil
+}
+
+// ListFollowers 获取用户的粉丝列表
+func (s *userService) ListFollowers(ctx context.Context, req *blogpb.ListFollowsRequest) (*blogpb.ListUsersResponse, error) {
+	return s.listFollows(ctx, req, "/followers")
+}
+
+// ListFollowing 获取用户的关注列表
+func (s *userService) ListFollowing(ctx context.Context, req *blogpb.ListFollowsRequest) (*blogpb.ListUsersResponse, error) {
+	return s.listFollows(ctx, req, "/following")
+}
+
+// listFollows 获取粉丝或关注列表
+func (s *userService) listFollows(ctx context.Context, req *blogpb.ListFollowsRequest, suffix string) (*blogpb.ListUsersResponse, error) {
+	var users []models.UserBrief
+	path := withQuery("/api/users/"+pathID(req.Id)+suffix, pageQuery(req.Page, req.PageSize))
+	if err := s.call(ctx, http.MethodGet, path, nil, &users); err != nil {
+		return nil, err
+	}
+	return &blogpb.ListUsersResponse{Users: toUserBriefs(users)}, nil
+}
+
+// FollowUser 关注用户
+func (s *userService) FollowUser(ctx context.Context, req *blogpb.FollowUserRequest) (*emptypb.Empty, error) {
+	if err := s.call(ctx, http.MethodPost, "/api/users/"+pathID(req.Id)+"/follow", nil, nil); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// UnfollowUser 取消关注
+func (s *userService) UnfollowUser(ctx context.Context, req *blogpb.FollowUserRequest) (*emptypb.Empty, error) {
+	if err := s.call(ctx, http.MethodDelete, "/api/users/"+pathID(req.Id)+"/follow", nil, nil); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
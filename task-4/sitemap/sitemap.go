@@ -0,0 +1,287 @@
+package sitemap
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MaxURLs 单个站点地图文件允许的最大URL数量，超过后拆分并生成站点地图索引
+const MaxURLs = 50000
+
+const xmlns = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+// Entry 站点地图中的一个URL
+type Entry struct {
+	ID      uint // 文章ID，决定条目在站点地图中的顺序
+	Loc     string
+	LastMod time.Time
+}
+
+// Document 生成好的站点地图文件
+type Document struct {
+	Data    []byte
+	ETag    string
+	LastMod time.Time
+}
+
+// Sitemap 缓存生成好的站点地图，文章变化时只重新生成受影响的分页
+// 条目按ID排序后每MaxURLs个分为一页，新文章追加到最后一页，
+// 修改只影响所在页，删除会让所在页及之后的分页整体前移
+type Sitemap struct {
+	mu       sync.Mutex
+	pageSize int
+	loaded   bool
+	loading  bool
+	pending  []func() // 加载期间收到的变更，加载后重放
+	ids      []uint
+	entries  map[uint]Entry
+	pages    []*Document // nil表示需要重新生成
+	index    *Document
+}
+
+// Default 默认的站点地图缓存
+var Default = New(MaxURLs)
+
+// New 创建站点地图缓存，pageSize为每个文件的URL数量
+func New(pageSize int) *Sitemap {
+	return &Sitemap{pageSize: pageSize, entries: make(map[uint]Entry)}
+}
+
+// Loaded 是否已经加载全部条目
+func (s *Sitemap) Loaded() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.loaded
+}
+
+// BeginLoad 开始从数据库加载全部条目，之后收到的变更会在Reset时重放，
+// 避免加载查询和并发的文章修改交错导致遗漏。加载失败时调用AbortLoad
+func (s *Sitemap) BeginLoad() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loading = true
+	s.pending = nil
+}
+
+// AbortLoad 放弃本次加载
+func (s *Sitemap) AbortLoad() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loading = false
+	s.pending = nil
+}
+
+// Reset 使用全部条目重建缓存，并重放加载期间收到的变更
+func (s *Sitemap) Reset(entries []Entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries = make(map[uint]Entry, len(entries))
+	s.ids = make([]uint, 0, len(entries))
+	for _, e := range entries {
+		if _, ok := s.entries[e.ID]; !ok {
+			s.ids = append(s.ids, e.ID)
+		}
+		s.entries[e.ID] = e
+	}
+	sort.Slice(s.ids, func(i, j int) bool { return s.ids[i] < s.ids[j] })
+	s.pages = nil
+	s.index = nil
+	s.loaded = true
+	s.loading = false
+
+	for _, apply := range s.pending {
+		apply()
+	}
+	s.pending = nil
+}
+
+// Put 新增或更新条目
+func (s *Sitemap) Put(e Entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// 尚未加载时不需要记录，加载时会从数据库读取最新数据
+	if !s.loaded {
+		if s.loading {
+			s.pending = append(s.pending, func() { s.put(e) })
+		}
+		return
+	}
+	s.put(e)
+}
+
+// Remove 删除条目
+func (s *Sitemap) Remove(id uint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// 尚未加载时不需要记录，加载时会从数据库读取最新数据
+	if !s.loaded {
+		if s.loading {
+			s.pending = append(s.pending, func() { s.remove(id) })
+		}
+		return
+	}
+	s.remove(id)
+}
+
+func (s *Sitemap) put(e Entry) {
+	pos := sort.Search(len(s.ids), func(i int) bool { return s.ids[i] >= e.ID })
+	if pos < len(s.ids) && s.ids[pos] == e.ID {
+		s.entries[e.ID] = e
+		s.invalidate(pos/s.pageSize, false)
+		return
+	}
+
+	s.ids = append(s.ids, 0)
+	copy(s.ids[pos+1:], s.ids[pos:])
+	s.ids[pos] = e.ID
+	s.entries[e.ID] = e
+	s.invalidate(pos/s.pageSize, true)
+}
+
+func (s *Sitemap) remove(id uint) {
+	pos := sort.Search(len(s.ids), func(i int) bool { return s.ids[i] >= id })
+	if pos == len(s.ids) || s.ids[pos] != id {
+		return
+	}
+	s.ids = append(s.ids[:pos], s.ids[pos+1:]...)
+	delete(s.entries, id)
+	s.invalidate(pos/s.pageSize, true)
+}
+
+// invalidate 标记分页需要重新生成，shifted为true时之后的分页也一并失效
+func (s *Sitemap) invalidate(page int, shifted bool) {
+	s.index = nil
+	for i := page; i < len(s.pages); i++ {
+		s.pages[i] = nil
+		if !shifted {
+			break
+		}
+	}
+}
+
+// PageCount 分页数量，没有条目时也有一个空分页
+func (s *Sitemap) PageCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pageCount()
+}
+
+func (s *Sitemap) pageCount() int {
+	if len(s.ids) == 0 {
+		return 1
+	}
+	return (len(s.ids) + s.pageSize - 1) / s.pageSize
+}
+
+// Page 返回第page页（从0开始）的站点地图，超出范围时返回nil
+func (s *Sitemap) Page(page int) *Document {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.page(page)
+}
+
+func (s *Sitemap) page(page int) *Document {
+	count := s.pageCount()
+	if page < 0 || page >= count {
+		return nil
+	}
+	if len(s.pages) != count {
+		pages := make([]*Document, count)
+		copy(pages, s.pages)
+		s.pages = pages
+	}
+	if s.pages[page] == nil {
+		s.pages[page] = s.renderPage(page)
+	}
+	return s.pages[page]
+}
+
+// Index 返回站点地图索引，pageURL根据页码生成每个分页的地址
+func (s *Sitemap) Index(pageURL func(page int) string) *Document {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.index != nil {
+		return s.index
+	}
+
+	type sitemapRef struct {
+		Loc     string `xml:"loc"`
+		LastMod string `xml:"lastmod,omitempty"`
+	}
+	index := struct {
+		XMLName  xml.Name     `xml:"sitemapindex"`
+		Xmlns    string       `xml:"xmlns,attr"`
+		Sitemaps []sitemapRef `xml:"sitemap"`
+	}{Xmlns: xmlns}
+
+	var lastMod time.Time
+	for i := 0; i < s.pageCount(); i++ {
+		page := s.page(i)
+		index.Sitemaps = append(index.Sitemaps, sitemapRef{Loc: pageURL(i), LastMod: formatTime(page.LastMod)})
+		if page.LastMod.After(lastMod) {
+			lastMod = page.LastMod
+		}
+	}
+	s.index = newDocument(index, lastMod)
+	return s.index
+}
+
+// renderPage 生成一页站点地图
+func (s *Sitemap) renderPage(page int) *Document {
+	type url struct {
+		Loc     string `xml:"loc"`
+		LastMod string `xml:"lastmod,omitempty"`
+	}
+	urlset := struct {
+		XMLName xml.Name `xml:"urlset"`
+		Xmlns   string   `xml:"xmlns,attr"`
+		URLs    []url    `xml:"url"`
+	}{Xmlns: xmlns}
+
+	start := page * s.pageSize
+	end := start + s.pageSize
+	if end > len(s.ids) {
+		end = len(s.ids)
+	}
+
+	var lastMod time.Time
+	for _, id := range s.ids[start:end] {
+		e := s.entries[id]
+		urlset.URLs = append(urlset.URLs, url{Loc: e.Loc, LastMod: formatTime(e.LastMod)})
+		if e.LastMod.After(lastMod) {
+			lastMod = e.LastMod
+		}
+	}
+	return newDocument(urlset, lastMod)
+}
+
+// newDocument 序列化XML并计算ETag
+func newDocument(v interface{}, lastMod time.Time) *Document {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	// 结构固定，序列化不会失败
+	xml.NewEncoder(&buf).Encode(v)
+
+	sum := sha256.Sum256(buf.Bytes())
+	return &Document{
+		Data:    buf.Bytes(),
+		ETag:    `"` + hex.EncodeToString(sum[:16]) + `"`,
+		LastMod: lastMod,
+	}
+}
+
+// formatTime 按W3C日期时间格式输出
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
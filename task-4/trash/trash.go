@@ -0,0 +1,148 @@
+package trash
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/xhy/blog-api/config"
+	"github.com/xhy/blog-api/models"
+	"gorm.io/gorm"
+)
+
+// 每个事务最多彻底删除的文章或评论数
+const purgeBatchSize = 100
+
+// PurgeAt 回收站中的内容被彻底删除的时间
+func PurgeAt(deletedAt time.Time, retention time.Duration) time.Time {
+	return deletedAt.Add(retention)
+}
+
+// PurgePost 彻底删除一篇文章及其评论和附件，event为记录执行者的审计日志
+func PurgePost(ctx context.Context, id uint, event models.AuditEvent) error {
+	return purgePosts(ctx, []uint{id}, []models.AuditEvent{event})
+}
+
+// Purge 彻底删除回收站中超过保留期限的文章和评论，返回删除的文章数和评论数
+func Purge(ctx context.Context, retention time.Duration) (posts, comments int, err error) {
+	cutoff := time.Now().Add(-retention)
+
+	for {
+		var ids []uint
+		if err := config.DB.WithContext(ctx).Unscoped().Model(&models.Post{}).
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+			Order("id").Limit(purgeBatchSize).Pluck("id", &ids).Error; err != nil {
+			return posts, comments, err
+		}
+		if len(ids) == 0 {
+			break
+		}
+		if err := purgePosts(ctx, ids, systemPurgeEvents(models.TargetPost, ids)); err != nil {
+			return posts, comments, err
+		}
+		posts += len(ids)
+	}
+
+	for {
+		var ids []uint
+		if err := config.DB.WithContext(ctx).Unscoped().Model(&models.Comment{}).
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+			Order("id").Limit(purgeBatchSize).Pluck("id", &ids).Error; err != nil {
+			return posts, comments, err
+		}
+		if len(ids) == 0 {
+			break
+		}
+		if err := config.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := models.PurgeComments(tx, ids); err != nil {
+				return err
+			}
+			events := systemPurgeEvents(models.TargetComment, ids)
+			return tx.Create(&events).Error
+		}); err != nil {
+			return posts, comments, err
+		}
+		comments += len(ids)
+	}
+
+	return posts, comments, nil
+}
+
+// purgePosts 在一个事务中删除文章的数据库记录并写入审计日志，提交后再删除附件文件
+// 文件删除失败只记录日志，残留的文件不影响数据一致性
+func purgePosts(ctx context.Context, ids []uint, events []models.AuditEvent) error {
+	var attachments []models.Attachment
+	if err := config.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		if attachments, err = models.PurgePosts(tx, ids); err != nil {
+			return err
+		}
+		return tx.Create(&events).Error
+	}); err != nil {
+		return err
+	}
+
+	for _, attachment := range attachments {
+		for _, key := range []string{attachment.StorageKey, attachment.ThumbnailKey} {
+			if key == "" {
+				continue
+			}
+			if err := config.Storage.Delete(ctx, key); err != nil {
+				log.Printf("删除附件文件 %s 失败: %v", key, err)
+			}
+		}
+	}
+	return nil
+}
+
+// systemPurgeEvents 超过保留期限自动清理时的审计日志，执行者为系统
+func systemPurgeEvents(targetType string, ids []uint) []models.AuditEvent {
+	events := make([]models.AuditEvent, len(ids))
+	for i, id := range ids {
+		events[i] = models.AuditEvent{
+			ActorName:  models.AuditSystem,
+			Action:     models.AuditPurge,
+			TargetType: targetType,
+			TargetID:   id,
+			Changes:    map[string]models.AuditChange{},
+		}
+	}
+	return events
+}
+
+// Start 启动后台定期清理，返回的stop函数停止清理并等待正在进行的清理结束
+func Start(interval, retention time.Duration) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	finished := make(chan struct{})
+
+	run := func() {
+		posts, comments, err := Purge(ctx, retention)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("清理回收站失败: %v", err)
+		}
+		if posts > 0 || comments > 0 {
+			log.Printf("清理回收站：彻底删除 %d 篇文章、%d 条评论", posts, comments)
+		}
+	}
+
+	go func() {
+		defer close(finished)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		run()
+		for {
+			select {
+			case <-ticker.C:
+				run()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-finished
+	}
+}
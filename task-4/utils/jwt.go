@@ -13,6 +13,17 @@ var (
 	JWTDuration = 24 * time.Hour       // 默认过期时间
 )
 
+// JWT密钥环和声明校验配置
+var (
+	JWTKeys     *KeyRing      // 非对称签名密钥环，为空时使用JWTSecret进行HS256签名
+	JWTIssuer   string        // 签发者（iss），为空时不校验
+	JWTAudience string        // 受众（aud），为空时不校验
+	JWTLeeway   time.Duration // 校验时间类声明时允许的时钟偏差
+
+	// 在此之前仍接受引入密钥环之前签发的令牌（没有kid，使用JWTSecret进行HS256签名），零值表示不接受
+	JWTLegacyUntil time.Time
+)
+
 // SetJWTSecret 设置JWT密钥
 func SetJWTSecret(secret string) {
 	JWTSecret = []byte(secret)
@@ -23,6 +34,73 @@ func SetJWTDuration(duration time.Duration) {
 	JWTDuration = duration
 }
 
+// SetJWTIssuer 设置JWT签发者
+func SetJWTIssuer(issuer string) {
+	JWTIssuer = issuer
+}
+
+// SetJWTAudience 设置JWT受众
+func SetJWTAudience(audience string) {
+	JWTAudience = audience
+}
+
+// SetJWTLeeway 设置JWT时钟偏差容忍度
+func SetJWTLeeway(leeway time.Duration) {
+	JWTLeeway = leeway
+}
+
+// SetJWTLegacyUntil 设置接受旧版HS256令牌的截止时间
+func SetJWTLegacyUntil(until time.Time) {
+	JWTLegacyUntil = until
+}
+
+// InitJWTKeys 根据签名算法初始化密钥环
+// HS256使用共享密钥；非对称算法从密钥目录加载私钥，目录为空时生成第一把密钥
+func InitJWTKeys(alg, dir, activeKID string) error {
+	if alg == "" || alg == AlgHS256 {
+		JWTKeys = nil
+		return nil
+	}
+
+	ring, err := LoadKeyRing(dir, alg, activeKID)
+	if err != nil {
+		return err
+	}
+	if ring.Active() == nil {
+		key, err := RotateSigningKey(dir, alg)
+		if err != nil {
+			return err
+		}
+		ring.Add(key)
+	}
+
+	JWTKeys = ring
+	return nil
+}
+
+// RotateSigningKey 生成新的签名密钥并写入密钥目录
+// 重启后新密钥成为激活密钥，旧密钥文件保留期间仍可验证已签发的令牌
+func RotateSigningKey(dir, alg string) (*SigningKey, error) {
+	key, err := GenerateSigningKey(alg)
+	if err != nil {
+		return nil, err
+	}
+	if err := SaveSigningKey(dir, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// jwtKeyRing 返回当前使用的密钥环
+func jwtKeyRing() *KeyRing {
+	if JWTKeys != nil {
+		return JWTKeys
+	}
+	ring := NewKeyRing()
+	ring.Add(NewHMACKey("default", JWTSecret))
+	return ring
+}
+
 // Claims JWT声明
 type Claims struct {
 	UserID   uint   `json:"user_id"`
@@ -40,17 +118,23 @@ func GenerateToken(userID uint, username string) (string, error) {
 		UserID:   userID,
 		Username: username,
 		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    JWTIssuer,
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 		},
 	}
+	if JWTAudience != "" {
+		claims.Audience = jwt.ClaimStrings{JWTAudience}
+	}
 
-	// 使用HS256算法创建令牌
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	// 使用激活密钥的算法创建令牌，并在头部写入kid
+	key := jwtKeyRing().Active()
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
 
-	// 使用密钥签名令牌
-	tokenString, err := token.SignedString(JWTSecret)
+	// 使用私钥签名令牌
+	tokenString, err := token.SignedString(key.Private)
 	if err != nil {
 		return "", err
 	}
@@ -60,14 +144,38 @@ func GenerateToken(userID uint, username string) (string, error) {
 
 // ParseToken 解析JWT令牌
 func ParseToken(tokenString string) (*Claims, error) {
+	if time.Now().Before(JWTLegacyUntil) && !hasKID(tokenString) {
+		return parseLegacyToken(tokenString)
+	}
+
+	ring := jwtKeyRing()
+
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods(ring.Methods()),
+		jwt.WithLeeway(JWTLeeway),
+		jwt.WithExpirationRequired(),
+	}
+	if JWTIssuer != "" {
+		options = append(options, jwt.WithIssuer(JWTIssuer))
+	}
+	if JWTAudience != "" {
+		options = append(options, jwt.WithAudience(JWTAudience))
+	}
+
 	// 解析令牌
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		// 验证签名算法
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		// 根据kid查找验证密钥
+		kid, _ := token.Header["kid"].(string)
+		key, ok := ring.Get(kid)
+		if !ok {
+			return nil, errors.New("unknown signing key")
+		}
+		// 验证签名算法，防止算法混淆攻击
+		if token.Method.Alg() != key.Method.Alg() {
 			return nil, errors.New("unexpected signing method")
 		}
-		return JWTSecret, nil
-	})
+		return key.Public, nil
+	}, options...)
 
 	if err != nil {
 		return nil, err
@@ -80,3 +188,29 @@ func ParseToken(tokenString string) (*Claims, error) {
 
 	return nil, errors.New("invalid token")
 }
+
+// hasKID 判断令牌头部是否带有kid，只读取头部，不验证签名
+func hasKID(tokenString string) bool {
+	token, _, err := jwt.NewParser().ParseUnverified(tokenString, &Claims{})
+	if err != nil {
+		return false
+	}
+	kid, _ := token.Header["kid"].(string)
+	return kid != ""
+}
+
+// parseLegacyToken 解析引入密钥环之前签发的令牌：HS256签名，没有kid、iss和aud
+// 只在过渡期内使用，这类令牌最迟在签发JWTDuration后过期
+func parseLegacyToken(tokenString string) (*Claims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
+		return JWTSecret, nil
+	}, jwt.WithValidMethods([]string{AlgHS256}), jwt.WithLeeway(JWTLeeway), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, err
+	}
+
+	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
+		return claims, nil
+	}
+	return nil, errors.New("invalid token")
+}
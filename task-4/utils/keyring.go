@@ -0,0 +1,250 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// 支持的签名算法
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgES256 = "ES256"
+	AlgEdDSA = "EdDSA"
+)
+
+// SigningKey JWT签名密钥
+type SigningKey struct {
+	ID      string
+	Method  jwt.SigningMethod
+	Private crypto.PrivateKey // HMAC算法时为[]byte
+	Public  crypto.PublicKey  // HMAC算法时为[]byte，不会对外公布
+}
+
+// Symmetric 判断是否为对称密钥
+func (k *SigningKey) Symmetric() bool {
+	_, ok := k.Method.(*jwt.SigningMethodHMAC)
+	return ok
+}
+
+// KeyRing 密钥环
+// 只用当前激活的密钥签名，环中所有密钥都可以用来验证，
+// 轮换时新密钥加入后旧密钥仍然有效，直到被移出密钥目录
+type KeyRing struct {
+	mu     sync.RWMutex
+	keys   map[string]*SigningKey
+	active string
+}
+
+// NewKeyRing 创建空的密钥环
+func NewKeyRing() *KeyRing {
+	return &KeyRing{keys: make(map[string]*SigningKey)}
+}
+
+// Add 添加密钥，第一个添加的密钥自动成为激活密钥
+func (r *KeyRing) Add(key *SigningKey) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.keys[key.ID] = key
+	if r.active == "" {
+		r.active = key.ID
+	}
+}
+
+// Activate 切换用于签名的密钥
+func (r *KeyRing) Activate(kid string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.keys[kid]; !ok {
+		return fmt.Errorf("signing key %q not found", kid)
+	}
+	r.active = kid
+	return nil
+}
+
+// Active 返回当前激活的密钥
+func (r *KeyRing) Active() *SigningKey {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.keys[r.active]
+}
+
+// Get 根据kid查找密钥
+func (r *KeyRing) Get(kid string) (*SigningKey, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	key, ok := r.keys[kid]
+	return key, ok
+}
+
+// Keys 返回按kid排序的全部密钥
+func (r *KeyRing) Keys() []*SigningKey {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	keys := make([]*SigningKey, 0, len(r.keys))
+	for _, key := range r.keys {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ID < keys[j].ID })
+	return keys
+}
+
+// Methods 返回环中密钥使用的全部签名算法
+func (r *KeyRing) Methods() []string {
+	seen := make(map[string]bool)
+	var methods []string
+	for _, key := range r.Keys() {
+		alg := key.Method.Alg()
+		if !seen[alg] {
+			seen[alg] = true
+			methods = append(methods, alg)
+		}
+	}
+	return methods
+}
+
+// NewHMACKey 使用共享密钥创建HS256签名密钥
+func NewHMACKey(kid string, secret []byte) *SigningKey {
+	return &SigningKey{
+		ID:      kid,
+		Method:  jwt.SigningMethodHS256,
+		Private: secret,
+		Public:  secret,
+	}
+}
+
+// GenerateSigningKey 生成指定算法的非对称签名密钥，kid使用生成时间加随机后缀，保证新密钥排在最后，
+// 同一秒内多次生成也不会重复
+func GenerateSigningKey(alg string) (*SigningKey, error) {
+	var private crypto.PrivateKey
+	var err error
+	switch alg {
+	case AlgRS256:
+		private, err = rsa.GenerateKey(rand.Reader, 2048)
+	case AlgES256:
+		private, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case AlgEdDSA:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm %q", alg)
+	}
+	if err != nil {
+		return nil, err
+	}
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, err
+	}
+	return newSigningKey(time.Now().UTC().Format("20060102T150405Z")+"-"+hex.EncodeToString(suffix), private)
+}
+
+// newSigningKey 根据私钥类型确定签名算法
+func newSigningKey(kid string, private crypto.PrivateKey) (*SigningKey, error) {
+	key := &SigningKey{ID: kid, Private: private}
+	switch k := private.(type) {
+	case *rsa.PrivateKey:
+		key.Method = jwt.SigningMethodRS256
+		key.Public = &k.PublicKey
+	case *ecdsa.PrivateKey:
+		switch k.Curve {
+		case elliptic.P256():
+			key.Method = jwt.SigningMethodES256
+		case elliptic.P384():
+			key.Method = jwt.SigningMethodES384
+		case elliptic.P521():
+			key.Method = jwt.SigningMethodES512
+		default:
+			return nil, errors.New("unsupported elliptic curve")
+		}
+		key.Public = &k.PublicKey
+	case ed25519.PrivateKey:
+		key.Method = jwt.SigningMethodEdDSA
+		key.Public = k.Public()
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T", private)
+	}
+	return key, nil
+}
+
+// LoadKeyRing 从目录加载PEM格式（PKCS#8）的私钥，文件名（去掉.pem）作为kid
+// 密钥类型与alg不一致时返回错误；未指定activeKID时使用kid最大（最新生成）的密钥签名
+func LoadKeyRing(dir, alg, activeKID string) (*KeyRing, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	ring := NewKeyRing()
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		block, _ := pem.Decode(data)
+		if block == nil {
+			return nil, fmt.Errorf("%s: invalid PEM data", file)
+		}
+		private, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		key, err := newSigningKey(strings.TrimSuffix(filepath.Base(file), ".pem"), private)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		if key.Method.Alg() != alg {
+			return nil, fmt.Errorf("%s: %s key does not match signing algorithm %s", file, key.Method.Alg(), alg)
+		}
+		ring.Add(key)
+	}
+
+	keys := ring.Keys()
+	if len(keys) == 0 {
+		return ring, nil
+	}
+	if activeKID == "" {
+		activeKID = keys[len(keys)-1].ID
+	}
+	if err := ring.Activate(activeKID); err != nil {
+		return nil, err
+	}
+	return ring, nil
+}
+
+// SaveSigningKey 将私钥以PKCS#8 PEM格式写入目录，同名文件已存在时返回错误，不会覆盖仍在使用的密钥
+func SaveSigningKey(dir string, key *SigningKey) error {
+	der, err := x509.MarshalPKCS8PrivateKey(key.Private)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	f, err := os.OpenFile(filepath.Join(dir, key.ID+".pem"), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/mozillazg/go-pinyin"
+	"golang.org/x/text/unicode/norm"
+)
+
+// SlugMaxLength 自动生成的别名最大长度，留出追加序号的空间
+const SlugMaxLength = 80
+
+var (
+	pinyinArgs = pinyin.NewArgs()
+	slugFormat = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
+)
+
+// Slugify 根据标题生成URL别名：中文转为不带声调的拼音，
+// 带附加符号的拉丁字母去掉符号，其他字符作为分隔符，结果只包含小写字母、数字和连字符
+func Slugify(title string) string {
+	var words []string
+	var word strings.Builder
+	flush := func() {
+		if word.Len() > 0 {
+			words = append(words, word.String())
+			word.Reset()
+		}
+	}
+
+	for _, r := range norm.NFD.String(title) {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			// 每个汉字的拼音作为一个单词
+			flush()
+			if py := pinyin.LazyPinyin(string(r), pinyinArgs); len(py) > 0 {
+				words = append(words, py[0])
+			}
+		case unicode.Is(unicode.Mn, r):
+			// 分解后的附加符号，如é中的重音
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			word.WriteRune(unicode.ToLower(r))
+		default:
+			flush()
+		}
+	}
+	flush()
+
+	// 超长时在单词边界截断
+	var slug strings.Builder
+	for _, w := range words {
+		if slug.Len() > 0 && slug.Len()+1+len(w) > SlugMaxLength {
+			break
+		}
+		if slug.Len() > 0 {
+			slug.WriteByte('-')
+		}
+		slug.WriteString(w)
+	}
+	if slug.Len() > SlugMaxLength {
+		return strings.TrimRight(slug.String()[:SlugMaxLength], "-")
+	}
+	return slug.String()
+}
+
+// IsValidSlug 检查别名是否只包含小写字母、数字和单个连字符
+func IsValidSlug(slug string) bool {
+	return len(slug) <= 200 && slugFormat.MatchString(slug)
+}
@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+)
+
+// AccessTokenPrefix 个人访问令牌前缀，用于和JWT区分
+const AccessTokenPrefix = "blog_pat_"
+
+// GenerateAccessToken 生成个人访问令牌，返回明文、哈希和用于展示的前缀
+func GenerateAccessToken() (token, hash, display string, err error) {
+	buf := make([]byte, 32)
+	if _, err = rand.Read(buf); err != nil {
+		return "", "", "", err
+	}
+	token = AccessTokenPrefix + base64.RawURLEncoding.EncodeToString(buf)
+	display = token[:len(AccessTokenPrefix)+6]
+	return token, HashAccessToken(token), display, nil
+}
+
+// HashAccessToken 计算访问令牌的哈希值
+// 令牌本身是高熵随机值，使用SHA-256即可，不需要bcrypt这类慢哈希
+func HashAccessToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsAccessToken 判断字符串是否为个人访问令牌
+func IsAccessToken(token string) bool {
+	return strings.HasPrefix(token, AccessTokenPrefix)
+}
@@ -0,0 +1,182 @@
+package views
+
+import (
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xhy/blog-api/config"
+	"github.com/xhy/blog-api/models"
+	"gorm.io/gorm"
+)
+
+// 每条UPDATE语句最多更新的文章数
+const flushBatchSize = 200
+
+// 常见爬虫、链接预览和命令行工具的User-Agent
+var botPattern = regexp.MustCompile(`(?i)bot|crawl|spider|slurp|archiver|facebookexternalhit|embedly|preview|monitor|headless|lighthouse|curl|wget|python-requests|go-http-client|okhttp|java/`)
+
+// Default 默认的阅读量计数器
+var Default = NewCounter(30 * time.Minute)
+
+// viewKey 去重键，同一访客在时间窗口内重复阅读同一篇文章只计一次
+type viewKey struct {
+	postID uint
+	viewer string
+}
+
+// Counter 在内存中累计文章阅读量，定期批量写入数据库
+type Counter struct {
+	mu       sync.Mutex
+	window   time.Duration
+	seen     map[viewKey]time.Time
+	pending  map[uint]int64 // 尚未写入数据库的阅读量
+	flushing map[uint]int64 // 正在写入数据库的阅读量
+	flushMu  sync.Mutex
+}
+
+// NewCounter 创建计数器，window为去重的时间窗口
+func NewCounter(window time.Duration) *Counter {
+	return &Counter{
+		window:  window,
+		seen:    make(map[viewKey]time.Time),
+		pending: make(map[uint]int64),
+	}
+}
+
+// SetWindow 设置去重的时间窗口
+func (c *Counter) SetWindow(window time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.window = window
+}
+
+// IsBot 判断User-Agent是否为爬虫等非真实访客，空User-Agent同样视为爬虫
+func IsBot(userAgent string) bool {
+	return strings.TrimSpace(userAgent) == "" || botPattern.MatchString(userAgent)
+}
+
+// Record 记录一次阅读，viewer为访客标识（用户ID或IP），返回是否计入阅读量
+func (c *Counter) Record(postID uint, viewer string) bool {
+	now := time.Now()
+	key := viewKey{postID: postID, viewer: viewer}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if last, ok := c.seen[key]; ok && now.Sub(last) < c.window {
+		return false
+	}
+	c.seen[key] = now
+	c.pending[postID]++
+	return true
+}
+
+// Pending 返回文章尚未写入数据库的阅读量，用于在响应中显示最新的阅读量
+func (c *Counter) Pending(postID uint) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.pending[postID] + c.flushing[postID]
+}
+
+// Flush 将累计的阅读量批量写入数据库，写入失败的部分留到下次重试
+func (c *Counter) Flush() error {
+	c.flushMu.Lock()
+	defer c.flushMu.Unlock()
+
+	c.mu.Lock()
+	batch := c.pending
+	c.pending = make(map[uint]int64)
+	c.flushing = batch
+	c.prune(time.Now())
+	c.mu.Unlock()
+
+	if len(batch) == 0 {
+		return nil
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		ids := make([]uint, 0, flushBatchSize)
+		var expr strings.Builder
+		var args []interface{}
+
+		for id, n := range batch {
+			if len(ids) == 0 {
+				expr.Reset()
+				expr.WriteString("view_count + CASE id")
+				args = args[:0]
+			}
+			ids = append(ids, id)
+			expr.WriteString(" WHEN ? THEN ?")
+			args = append(args, id, n)
+
+			if len(ids) == flushBatchSize {
+				if err := flushBatch(tx, ids, expr.String(), args); err != nil {
+					return err
+				}
+				ids = ids[:0]
+			}
+		}
+		if len(ids) > 0 {
+			return flushBatch(tx, ids, expr.String(), args)
+		}
+		return nil
+	})
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.flushing = nil
+	if err != nil {
+		for id, n := range batch {
+			c.pending[id] += n
+		}
+	}
+	return err
+}
+
+// flushBatch 用一条UPDATE语句增加多篇文章的阅读量，不改变文章的修改时间
+func flushBatch(tx *gorm.DB, ids []uint, expr string, args []interface{}) error {
+	return tx.Model(&models.Post{}).Where("id IN ?", ids).
+		UpdateColumn("view_count", gorm.Expr(expr+" ELSE 0 END", args...)).Error
+}
+
+// prune 清理已经超出去重窗口的记录，调用方需持有锁
+func (c *Counter) prune(now time.Time) {
+	for key, last := range c.seen {
+		if now.Sub(last) >= c.window {
+			delete(c.seen, key)
+		}
+	}
+}
+
+// Start 启动后台定期写入，返回的stop函数停止定时器并写入剩余的阅读量
+func (c *Counter) Start(interval time.Duration) (stop func()) {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	finished := make(chan struct{})
+
+	go func() {
+		defer close(finished)
+		for {
+			select {
+			case <-ticker.C:
+				if err := c.Flush(); err != nil {
+					log.Printf("写入阅读量失败: %v", err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		ticker.Stop()
+		close(done)
+		<-finished
+		if err := c.Flush(); err != nil {
+			log.Printf("写入阅读量失败: %v", err)
+		}
+	}
+}